@@ -10,13 +10,18 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-const (
+var (
 	PostTimeout = 5 * time.Second  //время на отправку
 	GetTimeout  = 10 * time.Second //время на получение информации
 )
 
 var userService UserService
 
+// SetTimeouts задает время на обработку запросов изменения и чтения.
+func SetTimeouts(post, get time.Duration) {
+	PostTimeout, GetTimeout = post, get
+}
+
 func SetService(userSvc UserService) {
 	userService = userSvc
 }
@@ -24,33 +29,98 @@ func SetService(userSvc UserService) {
 func Login(c echo.Context) error {
 	var req models.LoginRequest
 	if err := c.Bind(&req); err != nil { //получение и преобразование из json в удобный для go структуру
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Неверный формат данных",
-		})
+		return errInvalidBody
+	}
+	if err := userService.ValidateLoginRequest(&req); err != nil {
+		return err
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
 	defer cancel()
 
-	user, err := userService.Authenticate(ctx, req.Login, req.Password)
+	user, err := userService.Authenticate(ctx, req.Login, req.Password, c.RealIP())
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "Неверный логин или пароль",
+		return err
+	}
+
+	// Включен второй фактор: вместо JWT выдаем токен для второго шага входа
+	if user.MFAEnabled {
+		mfaToken, err := services.GenerateMFAChallenge(user)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
 		})
 	}
-	// Генерируем JWT токен
-	token, err := services.GenerateToken(user.ID, user.Login, user.Role)
+
+	// Генерируем JWT и refresh токен
+	resp, err := userService.IssueTokens(ctx, user, false)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Ошибка при создании токена",
-		})
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// LoginMFA второй шаг входа: код TOTP или код восстановления в обмен на JWT.
+func LoginMFA(c echo.Context) error {
+	var req models.MFALoginRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := required("mfa_token", req.MFAToken, "code", req.Code); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	resp, err := userService.CompleteMFALogin(ctx, req.MFAToken, req.Code, c.RealIP())
+	if err != nil {
+		return err
 	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RefreshToken выдает новую пару токенов в обмен на действующий refresh токен.
+func RefreshToken(c echo.Context) error {
+	var req models.RefreshRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := required("refresh_token", req.RefreshToken); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	resp, err := userService.RotateRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
 
-	// Очищаем пароль перед отправкой
-	user.Password = ""
+// Logout отзывает текущий access токен и переданный refresh токен.
+func Logout(c echo.Context) error {
+	var req models.LogoutRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	claims := c.Get("claims").(*models.JwtUser)
+	if err := userService.Logout(ctx, claims, req.RefreshToken); err != nil {
+		return err
+	}
+	return messageJSON(c, http.StatusOK, "logged_out")
+}
 
-	return c.JSON(http.StatusOK, models.AuthResponse{
-		Token: token,
-		User:  *user,
-	})
+// JWKS отдает публичные ключи, которыми другие сервисы могут проверять наши токены.
+func JWKS(c echo.Context) error {
+	return c.JSON(http.StatusOK, services.Keys.JWKS())
 }
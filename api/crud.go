@@ -2,8 +2,6 @@ package api
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"net/http"
 	"strconv"
 	"work/models"
@@ -11,16 +9,20 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// GetAll возвращает страницу пользователей: ?limit=&cursor=&role=&login_prefix=&sort=-login
 func GetAll(c echo.Context) error {
 	ctx, cancel := context.WithTimeout(c.Request().Context(), GetTimeout)
 	defer cancel()
-	allUsers, err := userService.GetAllUsers(ctx)
+	var q models.UserListQuery
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &q); err != nil {
+		return errInvalidQuery
+	}
+	page, err := userService.ListUsers(ctx, q)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError,
-			map[string]string{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(http.StatusOK, allUsers)
+	return c.JSON(http.StatusOK, page)
 }
 
 func CreateUser(c echo.Context) error {
@@ -29,26 +31,12 @@ func CreateUser(c echo.Context) error {
 	user := new(models.User)
 
 	if err := c.Bind(user); err != nil {
-		return c.JSON(http.StatusBadRequest,
-			map[string]string{"error": "Недопустимое значение"})
-	}
-
-	if user.Login == "" || user.Password == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Логин и пароль обязательны",
-		})
+		return errInvalidBody
 	}
 
 	// Используем интерфейс UserService
-	err := userService.CreateUser(ctx, user)
-	if err != nil {
-		if err.Error() == "пользователь с таким логином уже существует" {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "Пользователь уже существует",
-			})
-		}
-		return c.JSON(http.StatusInternalServerError,
-			map[string]string{"error": err.Error()})
+	if err := userService.CreateUser(ctx, user); err != nil {
+		return err
 	}
 	user.Password = "" // очищаем значение для безопасности, перед выводом
 	return c.JSON(http.StatusCreated, user)
@@ -59,20 +47,16 @@ func UpdateUser(c echo.Context) error {
 	defer cancel()
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest,
-			map[string]string{"error": "Ошибка ID формата"})
+		return errInvalidID
 	}
 
 	user := new(models.User)
 	if err := c.Bind(user); err != nil {
-		return c.JSON(http.StatusBadRequest,
-			map[string]string{"error": "Недопустимое значение"})
+		return errInvalidBody
 	}
 	user.ID = id
-	err = userService.UpdateUser(ctx, user)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError,
-			map[string]string{"error": err.Error()})
+	if err = userService.UpdateUser(ctx, user); err != nil {
+		return err
 	}
 	user.Password = "" //скрываем пароль(хэш)
 	return c.JSON(http.StatusOK, user)
@@ -83,25 +67,44 @@ func DeleteUser(c echo.Context) error {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest,
-			map[string]string{"error": "Ошибка ID формата"})
+		return errInvalidID
 	}
 	currentUserID := c.Get("user_id").(int)
 	if id == currentUserID {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Нельзя удалить самого себя",
-		})
+		return errDeleteSelf
 	}
 	// Используем интерфейс UserService
-	err = userService.DeleteUser(ctx, id)
+	if err = userService.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+
+	return messageJSON(c, http.StatusOK, "user_deleted")
+}
+
+// RevokeUserSessions завершает все сессии пользователя (access и refresh токены).
+func RevokeUserSessions(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "Пользователь не найден"})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return errInvalidID
+	}
+	if err = userService.RevokeUserSessions(ctx, id); err != nil {
+		return err
 	}
+	return messageJSON(c, http.StatusOK, "sessions_revoked")
+}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Пользователь удален",
-	})
+// UnlockUser снимает блокировку входа, наложенную после неудачных попыток.
+func UnlockUser(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidID
+	}
+	if err = userService.UnlockUser(ctx, id); err != nil {
+		return err
+	}
+	return messageJSON(c, http.StatusOK, "user_unlocked")
 }
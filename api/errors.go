@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"work/models"
+	"work/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// MIMEProblemJSON тип содержимого ответов с ошибкой (RFC 7807).
+	MIMEProblemJSON = "application/problem+json"
+	// ProblemTypePrefix префикс поля type; полный тип — префикс и код ошибки.
+	ProblemTypePrefix = "urn:problem-type:"
+)
+
+var (
+	errInvalidBody  = services.NewError(services.ErrValidation, "invalid_body", "неверный формат данных")
+	errInvalidQuery = services.NewError(services.ErrValidation, "invalid_query", "недопустимые параметры запроса")
+	errInvalidID    = services.NewFieldError("id", "invalid_id", "некорректный идентификатор")
+	errDeleteSelf   = services.NewError(services.ErrValidation, "delete_self", "нельзя удалить самого себя")
+
+	// errInternal отдается вместо ошибок без категории; подробности только в логе.
+	errInternal = services.NewError(nil, "internal_error", "внутренняя ошибка сервера")
+)
+
+// required проверяет, что обязательные поля запроса заполнены.
+// Аргументы — пары: имя поля в JSON и значение.
+func required(pairs ...string) error {
+	var fields []models.FieldError
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if strings.TrimSpace(pairs[i+1]) == "" {
+			fields = append(fields, models.FieldError{Field: pairs[i], Code: "required", Message: "обязательное поле"})
+		}
+	}
+	if fields == nil {
+		return nil
+	}
+	return services.NewValidationError(fields...)
+}
+
+// HTTPErrorHandler отвечает на любую ошибку обработчика или middleware телом
+// application/problem+json. Доменные ошибки services переводятся в статус по категории,
+// echo.HTTPError — по своему коду, остальное — 500 без подробностей.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	problem, params := newProblem(err)
+	problem.Instance = c.Request().URL.Path
+	problem.Detail = translate(c, problem.Code, problem.Detail, params)
+	for i, field := range problem.Errors {
+		problem.Errors[i].Message = translate(c, field.Code, field.Message, field.Params)
+	}
+
+	var lockErr *services.LockoutError
+	if errors.As(err, &lockErr) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(lockErr.RetryAfter.Seconds())+1))
+	}
+	if problem.Status == http.StatusInternalServerError {
+		slog.ErrorContext(c.Request().Context(), "ошибка обработки запроса",
+			"method", c.Request().Method, "route", c.Path(), "error", err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(problem.Status)
+	} else {
+		c.Response().Header().Set(echo.HeaderContentType, MIMEProblemJSON)
+		err = c.JSON(problem.Status, problem)
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "не удалось отправить ответ с ошибкой", "error", err)
+	}
+}
+
+// newProblem определяет статус, код и описание ошибки для клиента,
+// а также подстановки для перевода описания.
+func newProblem(err error) (*models.Problem, map[string]string) {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		p := problemFor(httpErr.Code, statusCode(httpErr.Code), "")
+		if msg, ok := httpErr.Message.(string); ok {
+			p.Detail = msg
+		}
+		return p, nil
+	}
+
+	var domainErr *services.Error
+	if !errors.As(err, &domainErr) {
+		domainErr = errInternal
+	}
+	p := problemFor(kindStatus(domainErr.Kind), domainErr.Code, domainErr.Message)
+	// копия: ошибки-образцы общие для всех запросов, а сообщения полей переводятся
+	p.Errors = slices.Clone(domainErr.Fields)
+	return p, domainErr.Params
+}
+
+func problemFor(status int, code, detail string) *models.Problem {
+	return &models.Problem{
+		Type:   ProblemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// kindStatus HTTP-статус для категории доменной ошибки.
+func kindStatus(kind error) int {
+	switch kind {
+	case services.ErrNotFound:
+		return http.StatusNotFound
+	case services.ErrConflict:
+		return http.StatusConflict
+	case services.ErrValidation:
+		return http.StatusBadRequest
+	case services.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case services.ErrForbidden:
+		return http.StatusForbidden
+	case services.ErrLocked:
+		return http.StatusLocked
+	case services.ErrRateLimited:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// statusCode код ошибки для echo.HTTPError (маршрут не найден, метод не разрешен и т.п.):
+// "Method Not Allowed" -> "method_not_allowed".
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
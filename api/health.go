@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"work/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	healthOK          = "ok"
+	healthUnavailable = "unavailable"
+
+	// readinessTimeout общее время на все проверки /readyz.
+	readinessTimeout = 3 * time.Second
+)
+
+var errShuttingDown = errors.New("сервер останавливается")
+
+type readinessCheck struct {
+	name  string
+	check func(context.Context) error
+}
+
+// AddReadinessCheck добавляет проверку зависимости для /readyz, например ping базы данных.
+func (s *Server) AddReadinessCheck(name string, check func(context.Context) error) {
+	s.checks = append(s.checks, readinessCheck{name: name, check: check})
+}
+
+// Healthz отвечает 200, пока процесс жив и обрабатывает запросы.
+func (s *Server) Healthz(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.HealthResponse{Status: healthOK})
+}
+
+// Readyz выполняет все проверки и отвечает 503, если хотя бы одна не прошла
+// или сервер уже останавливается.
+func (s *Server) Readyz(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), readinessTimeout)
+	defer cancel()
+
+	resp := models.HealthResponse{Status: healthOK, Checks: make(map[string]models.CheckResult, len(s.checks)+1)}
+	if s.stopping.Load() {
+		resp.Checks["shutdown"] = models.CheckResult{Status: healthUnavailable, Error: errShuttingDown.Error()}
+		resp.Status = healthUnavailable
+	}
+	for _, rc := range s.checks {
+		start := time.Now()
+		result := models.CheckResult{Status: healthOK}
+		if err := rc.check(ctx); err != nil {
+			result.Status = healthUnavailable
+			result.Error = err.Error()
+			resp.Status = healthUnavailable
+		}
+		result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		resp.Checks[rc.name] = result
+	}
+
+	status := http.StatusOK
+	if resp.Status != healthOK {
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, resp)
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"work/i18n"
+
+	"github.com/labstack/echo/v4"
+)
+
+const headerContentLanguage = "Content-Language"
+
+// messages каталог текстов ответов; по умолчанию — встроенные языки, русский основной.
+var messages = i18n.Must(i18n.NewBundle("ru"))
+
+// SetMessages задает каталог сообщений, например с дополнительными языками из файлов.
+func SetMessages(bundle *i18n.Bundle) {
+	messages = bundle
+}
+
+// requestLanguage выбирает язык ответа по Accept-Language и запоминает его в контексте.
+func requestLanguage(c echo.Context) string {
+	if lang, ok := c.Get("lang").(string); ok {
+		return lang
+	}
+	lang := messages.Match(c.Request().Header.Get("Accept-Language"))
+	c.Set("lang", lang)
+	header := c.Response().Header()
+	header.Set(headerContentLanguage, lang)
+	header.Add(echo.HeaderVary, "Accept-Language")
+	return lang
+}
+
+// translate текст по коду на языке запроса. Если кода нет в каталоге, возвращается fallback.
+func translate(c echo.Context, code, fallback string, params map[string]string) string {
+	if msg, ok := messages.Message(requestLanguage(c), code, params); ok {
+		return msg
+	}
+	return fallback
+}
+
+// messageJSON ответ {"message": "..."} с текстом из каталога по коду.
+func messageJSON(c echo.Context, status int, code string) error {
+	return c.JSON(status, map[string]string{"message": translate(c, code, code, nil)})
+}
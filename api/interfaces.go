@@ -7,10 +7,38 @@ import (
 
 type (
 	UserService interface {
-		Authenticate(ctx context.Context, login, password string) (*models.User, error)
-		GetAllUsers(ctx context.Context) ([]models.AllUser, error)
+		Authenticate(ctx context.Context, login, password, ip string) (*models.User, error)
+		ValidateLoginRequest(req *models.LoginRequest) error
+		ListUsers(ctx context.Context, q models.UserListQuery) (*models.UserPage, error)
 		CreateUser(ctx context.Context, user *models.User) error
 		UpdateUser(ctx context.Context, user *models.User) error
 		DeleteUser(ctx context.Context, id int) error
+		IssueTokens(ctx context.Context, user *models.User, mfa bool) (*models.AuthResponse, error)
+		RotateRefreshToken(ctx context.Context, token string) (*models.AuthResponse, error)
+		VerifyAccessToken(ctx context.Context, token string) (*models.JwtUser, error)
+		Authorize(claims *models.JwtUser, permission string) error
+		Logout(ctx context.Context, claims *models.JwtUser, refreshToken string) error
+		RevokeUserSessions(ctx context.Context, userID int) error
+		UnlockUser(ctx context.Context, id int) error
+		CompleteMFALogin(ctx context.Context, challenge, code, ip string) (*models.AuthResponse, error)
+		EnrollMFA(ctx context.Context, userID int) (*models.MFAEnrollResponse, error)
+		ConfirmMFA(ctx context.Context, userID int, code string) ([]string, error)
+		DisableMFA(ctx context.Context, userID int, code string) error
+		RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error)
+		GetUser(ctx context.Context, id int) (*models.User, error)
+		UpdateProfile(ctx context.Context, id int, req *models.UpdateProfileRequest) (*models.User, error)
+		ChangePassword(ctx context.Context, id int, currentPassword, newPassword, ip string) error
+		Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error)
+		VerifyEmail(ctx context.Context, token string) error
+		ResendVerification(ctx context.Context, email, ip string) error
+		RequestPasswordReset(ctx context.Context, email, ip string) error
+		ResetPassword(ctx context.Context, token, newPassword string) error
+		ListRoles(ctx context.Context) ([]models.Role, error)
+		GetRole(ctx context.Context, name string) (*models.Role, error)
+		CreateRole(ctx context.Context, role *models.Role) error
+		UpdateRole(ctx context.Context, role *models.Role) error
+		DeleteRole(ctx context.Context, name string) error
+		ListPermissions(ctx context.Context) ([]models.Permission, error)
+		WaitBackground(ctx context.Context) error
 	}
 )
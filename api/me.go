@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"work/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetMe возвращает профиль текущего пользователя.
+func GetMe(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), GetTimeout)
+	defer cancel()
+
+	user, err := userService.GetUser(ctx, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// UpdateMe изменяет профиль текущего пользователя. Роль изменить нельзя.
+func UpdateMe(c echo.Context) error {
+	var req models.UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	user, err := userService.UpdateProfile(ctx, c.Get("user_id").(int), &req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// ChangeMyPassword меняет пароль текущего пользователя. Все выданные ранее токены
+// отзываются, в ответе — новая пара токенов для текущего клиента.
+func ChangeMyPassword(c echo.Context) error {
+	var req models.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := required("current_password", req.CurrentPassword, "new_password", req.NewPassword); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	id := c.Get("user_id").(int)
+	err := userService.ChangePassword(ctx, id, req.CurrentPassword, req.NewPassword, c.RealIP())
+	if err != nil {
+		return err
+	}
+
+	user, err := userService.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	claims := c.Get("claims").(*models.JwtUser)
+	resp, err := userService.IssueTokens(ctx, user, slices.Contains(claims.AMR, "otp"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
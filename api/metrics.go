@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Метрики HTTP. Метка route — шаблон маршрута (/api/v1/admin/users/:id), а не путь,
+// чтобы идентификаторы не порождали новые ряды.
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Обработанные HTTP-запросы по маршруту и статусу.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Время обработки HTTP-запросов по маршруту и статусу.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// RegisterMetrics регистрирует метрики HTTP API.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{httpRequests, httpDuration} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Metrics считает запросы и время их обработки.
+func Metrics(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+		route := c.Path()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Response().Status)
+		httpRequests.WithLabelValues(c.Request().Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request().Method, route, status).Observe(time.Since(start).Seconds())
+		return nil
+	}
+}
+
+// MetricsServer отдает /metrics в формате Prometheus из реестра по умолчанию на отдельном
+// адресе (server.metrics_addr): метрики раскрывают маршруты и нагрузку и не должны быть
+// доступны через публичный порт API.
+type MetricsServer struct {
+	srv *http.Server
+}
+
+func NewMetricsServer() *MetricsServer {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+	return &MetricsServer{srv: &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}}
+}
+
+func (m *MetricsServer) Run(addr string) error {
+	m.srv.Addr = addr
+	return m.srv.ListenAndServe()
+}
+
+// Stop дожидается завершения текущих запросов.
+func (m *MetricsServer) Stop(ctx context.Context) error {
+	return m.srv.Shutdown(ctx)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"work/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// EnrollMFA выдает новый секрет TOTP и otpauth:// ссылку для приложения-аутентификатора.
+func EnrollMFA(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	resp, err := userService.EnrollMFA(ctx, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ConfirmMFA включает второй фактор по первому коду и возвращает коды восстановления.
+func ConfirmMFA(c echo.Context) error {
+	var req models.MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := required("code", req.Code); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	codes, err := userService.ConfirmMFA(ctx, c.Get("user_id").(int), req.Code)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA отключает второй фактор по действующему коду.
+func DisableMFA(c echo.Context) error {
+	var req models.MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := required("code", req.Code); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	if err := userService.DisableMFA(ctx, c.Get("user_id").(int), req.Code); err != nil {
+		return err
+	}
+	return messageJSON(c, http.StatusOK, "mfa_disabled")
+}
+
+// RegenerateRecoveryCodes выдает новый набор кодов восстановления, старые перестают действовать.
+func RegenerateRecoveryCodes(c echo.Context) error {
+	var req models.MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := required("code", req.Code); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	codes, err := userService.RegenerateRecoveryCodes(ctx, c.Get("user_id").(int), req.Code)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
@@ -1,72 +1,115 @@
 package api
 
 import (
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
 	"net/http"
-	"strings"
+	"regexp"
+	"time"
+	"work/logging"
 	"work/models"
 	"work/services"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
-func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+// HeaderRequestID заголовок с идентификатором запроса в запросе и ответе.
+const HeaderRequestID = "X-Request-ID"
+
+// requestIDPattern допустимый идентификатор от клиента или прокси; остальные заменяются новыми.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID берет идентификатор запроса из X-Request-ID или генерирует новый,
+// возвращает его в ответе и кладет в контекст запроса для логов.
+func RequestID(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		authHeader := c.Request().Header.Get("Authorization") //проверяем есть ли в заголовке запроса авторизация
-		if authHeader == "" {                                 //если отсутствует требуем аавторизацию
-			return c.JSON(http.StatusUnauthorized, map[string]string{
-				"error": "Требуется авторизация",
-			})
-		}
-		//проверка формата токена
-		parts := strings.Split(authHeader, " ")      //делим строку на 2 части по пробелу
-		if len(parts) != 2 || parts[0] != "Bearer" { //первое слово должно быть барьер
-			return c.JSON(http.StatusUnauthorized, map[string]string{
-				"error": "Неверный формат токена",
-			})
+		req := c.Request()
+		id := req.Header.Get(HeaderRequestID)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
 		}
+		c.Response().Header().Set(HeaderRequestID, id)
+		c.SetRequest(req.WithContext(logging.WithRequestID(req.Context(), id)))
+		return next(c)
+	}
+}
 
-		tokenString := parts[1] //записываем токен в переменную
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
 
-		// Проверяем токен
-		token, err := jwt.ParseWithClaims(tokenString, &models.JwtUser{}, func(token *jwt.Token) (interface{}, error) { //
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("неожиданный метод подписи: %v", token.Header["alg"])
-			}
-			return services.JwtSecret, nil
-		})
+// AccessLog пишет в лог каждый запрос: метод, путь, статус, время обработки и размер ответа.
+// request_id и user_id добавляются из контекста.
+func AccessLog(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		if err := next(c); err != nil {
+			// ответ с ошибкой формируется здесь, чтобы в лог попал итоговый статус
+			c.Error(err)
+		}
+		req, res := c.Request(), c.Response()
+		level := slog.LevelInfo
+		if isProbe(c.Path()) {
+			// проверки оркестратора идут каждые несколько секунд и забивают лог
+			level = slog.LevelDebug
+		}
+		if res.Status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(req.Context(), level, "http request",
+			slog.String("method", req.Method),
+			slog.String("path", req.URL.Path),
+			slog.String("route", c.Path()),
+			slog.Int("status", res.Status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int64("bytes", res.Size),
+			slog.String("remote_ip", c.RealIP()),
+		)
+		return nil
+	}
+}
 
+// isProbe маршруты проверок живости и готовности.
+func isProbe(route string) bool {
+	return route == "/healthz" || route == "/readyz"
+}
+
+func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		//токен из заголовка "Authorization: Bearer <token>"
+		token, err := services.BearerToken(c.Request().Header.Get("Authorization"))
 		if err != nil {
-			return c.JSON(http.StatusUnauthorized, map[string]string{
-				"error": "Неверный или истекший токен",
-			})
+			return err
 		}
-		//извлекаем данные о пользователе
-		if claims, ok := token.Claims.(*models.JwtUser); ok && token.Valid {
-			// Сохраняем данные пользователя в контекст
-			c.Set("user_id", claims.UserID)
-			c.Set("user_login", claims.Login)
-			c.Set("user_role", claims.Role)
-		} else {
-			return c.JSON(http.StatusUnauthorized, map[string]string{
-				"error": "Невалидный токен",
-			})
+		//проверка подписи, срока и отзыва — общая с gRPC
+		claims, err := userService.VerifyAccessToken(c.Request().Context(), token)
+		if err != nil {
+			return err
 		}
+		// Сохраняем данные пользователя в контекст
+		c.Set("user_id", claims.UserID)
+		c.Set("user_login", claims.Login)
+		c.Set("user_role", claims.Role)
+		c.Set("claims", claims)
+		c.SetRequest(c.Request().WithContext(logging.WithUserID(c.Request().Context(), claims.UserID)))
 
 		return next(c) //если все ок, то пропускаем дальше
 	}
 }
 
-// Middleware для проверки роли админа
-func AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		role := c.Get("user_role").(string) //получаем из "пакета" информацию о роле пользователя
-		if role != "admin" {                //если роль не админ, запрещаем доступ
-			return c.JSON(http.StatusForbidden, map[string]string{
-				"error": "Недостаточно прав. Требуется роль admin",
-			})
+// RequirePermission пропускает запрос, только если в токене есть право permission,
+// например "users:delete". Используется после AuthMiddleware.
+func RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := c.Get("claims").(*models.JwtUser) //получаем из "пакета" данные токена
+			if err := userService.Authorize(claims, permission); err != nil {
+				return err
+			}
+			return next(c) //если все ок, то пропускаем дальше
 		}
-		return next(c) //если все ок, то пропускаем дальше
 	}
 }
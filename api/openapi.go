@@ -0,0 +1,67 @@
+package api
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Спецификация поддерживается вручную вместе с SetupRoutes; UndocumentedRoutes
+// показывает маршруты, которые забыли описать.
+var (
+	//go:embed openapi/openapi.json
+	openAPISpec []byte
+	//go:embed openapi/swagger.html
+	swaggerPage []byte
+	//go:embed openapi/swagger-ui/*.js openapi/swagger-ui/*.css
+	swaggerFiles embed.FS
+)
+
+// swaggerAssets статика Swagger UI без префикса каталога: swagger-ui.css и т.д.
+var swaggerAssets, _ = fs.Sub(swaggerFiles, "openapi/swagger-ui")
+
+// OpenAPI отдает спецификацию API в формате OpenAPI 3.1.
+func OpenAPI(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, openAPISpec)
+}
+
+// SwaggerUI страница с интерактивной документацией по /api/openapi.json.
+// Статика Swagger UI встроена в бинарник и отдается SwaggerAsset.
+func SwaggerUI(c echo.Context) error {
+	return c.HTMLBlob(http.StatusOK, swaggerPage)
+}
+
+// SwaggerAsset отдает встроенный файл Swagger UI (скрипт или стили).
+func SwaggerAsset(c echo.Context) error {
+	return echo.StaticFileHandler(c.Param("file"), swaggerAssets)(c)
+}
+
+var echoParam = regexp.MustCompile(`:([^/]+)`)
+
+// UndocumentedRoutes возвращает маршруты Echo ("GET /api/v1/users"), которых нет в спецификации.
+func UndocumentedRoutes(routes []*echo.Route) ([]string, error) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, r := range routes {
+		if r.Method == echo.RouteNotFound {
+			continue
+		}
+		path := echoParam.ReplaceAllString(r.Path, "{$1}")
+		if _, ok := spec.Paths[path][strings.ToLower(r.Method)]; !ok {
+			missing = append(missing, r.Method+" "+r.Path)
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
@@ -0,0 +1 @@
+window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoutesDocumented(t *testing.T) {
+	s := New(nil)
+	missing, err := UndocumentedRoutes(s.e.Routes())
+	if err != nil {
+		t.Fatalf("спецификация OpenAPI: %v", err)
+	}
+	if len(missing) > 0 {
+		t.Errorf("маршруты не описаны в openapi.json: %v", missing)
+	}
+}
+
+func TestSwaggerAssetsEmbedded(t *testing.T) {
+	s := New(nil)
+	for _, file := range []string{"swagger-ui-bundle.js", "swagger-ui.css", "swagger-initializer.js"} {
+		rec := httptest.NewRecorder()
+		s.e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/docs/"+file, nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("%s: статус %d, %d байт", file, rec.Code, rec.Body.Len())
+		}
+	}
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/docs/README.md", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("файл вне встроенной статики: статус %d, ожидался 404", rec.Code)
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"work/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ForgotPassword отправляет ссылку для сброса пароля. Ответ не зависит от того, найден ли адрес.
+func ForgotPassword(c echo.Context) error {
+	var req models.EmailRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := required("email", req.Email); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	if err := userService.RequestPasswordReset(ctx, req.Email, c.RealIP()); err != nil {
+		return err
+	}
+	return messageJSON(c, http.StatusAccepted, "password_reset_sent")
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма.
+func ResetPassword(c echo.Context) error {
+	var req models.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := required("token", req.Token, "new_password", req.NewPassword); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	if err := userService.ResetPassword(ctx, req.Token, req.NewPassword); err != nil {
+		return err
+	}
+	return messageJSON(c, http.StatusOK, "password_changed")
+}
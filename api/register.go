@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"work/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Register самостоятельная регистрация с подтверждением адреса почты.
+func Register(c echo.Context) error {
+	var req models.RegisterRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	user, err := userService.Register(ctx, &req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, user)
+}
+
+// VerifyEmail подтверждает адрес почты по токену из письма.
+func VerifyEmail(c echo.Context) error {
+	var req models.VerifyEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := required("token", req.Token); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	if err := userService.VerifyEmail(ctx, req.Token); err != nil {
+		return err
+	}
+	return messageJSON(c, http.StatusOK, "email_verified")
+}
+
+// ResendVerification повторно отправляет письмо подтверждения. Ответ всегда одинаковый.
+func ResendVerification(c echo.Context) error {
+	var req models.EmailRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := required("email", req.Email); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+
+	if err := userService.ResendVerification(ctx, req.Email, c.RealIP()); err != nil {
+		return err
+	}
+	return messageJSON(c, http.StatusAccepted, "verification_sent")
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"work/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetRoles(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), GetTimeout)
+	defer cancel()
+	roles, err := userService.ListRoles(ctx)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, roles)
+}
+
+func GetRole(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), GetTimeout)
+	defer cancel()
+	role, err := userService.GetRole(ctx, c.Param("name"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, role)
+}
+
+func GetPermissions(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), GetTimeout)
+	defer cancel()
+	perms, err := userService.ListPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, perms)
+}
+
+func CreateRole(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+	role := new(models.Role)
+	if err := c.Bind(role); err != nil {
+		return errInvalidBody
+	}
+	if err := required("name", role.Name); err != nil {
+		return err
+	}
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	if err := userService.CreateRole(ctx, role); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, role)
+}
+
+func UpdateRole(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+	role := new(models.Role)
+	if err := c.Bind(role); err != nil {
+		return errInvalidBody
+	}
+	role.Name = c.Param("name")
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	if err := userService.UpdateRole(ctx, role); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, role)
+}
+
+func DeleteRole(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), PostTimeout)
+	defer cancel()
+	if err := userService.DeleteRole(ctx, c.Param("name")); err != nil {
+		return err
+	}
+	return messageJSON(c, http.StatusOK, "role_deleted")
+}
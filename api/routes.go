@@ -2,15 +2,50 @@ package api
 
 func (s *Server) SetupRoutes() {
 	// Публичные маршруты
-	s.e.GET("/api/v1/users", GetAll)
 	s.e.POST("/api/v1/login", Login)
+	s.e.POST("/api/v1/login/mfa", LoginMFA)
+	s.e.POST("/api/v1/token/refresh", RefreshToken)
+	s.e.GET("/.well-known/jwks.json", JWKS)
+	s.e.POST("/api/v1/register", Register)
+	s.e.POST("/api/v1/register/verify", VerifyEmail)
+	s.e.POST("/api/v1/register/resend", ResendVerification)
+	s.e.POST("/api/v1/password/forgot", ForgotPassword)
+	s.e.POST("/api/v1/password/reset", ResetPassword)
+	s.e.GET("/api/openapi.json", OpenAPI)
+	s.e.GET("/api/docs", SwaggerUI)
+	s.e.GET("/api/docs/:file", SwaggerAsset)
+	s.e.GET("/healthz", s.Healthz)
+	s.e.GET("/readyz", s.Readyz)
+
+	// Маршруты для авторизованных пользователей
+	s.e.POST("/api/v1/logout", Logout, AuthMiddleware)
+	s.e.GET("/api/v1/users", GetAll, AuthMiddleware, RequirePermission("users:read"))
+
+	// Собственная учетная запись
+	meGroup := s.e.Group("/api/v1/me")
+	meGroup.Use(AuthMiddleware)
+	meGroup.GET("", GetMe)
+	meGroup.PATCH("", UpdateMe)
+	meGroup.POST("/password", ChangeMyPassword)
+	meGroup.POST("/mfa/enroll", EnrollMFA)
+	meGroup.POST("/mfa/confirm", ConfirmMFA)
+	meGroup.POST("/mfa/disable", DisableMFA)
+	meGroup.POST("/mfa/recovery-codes", RegenerateRecoveryCodes)
 
 	// Защищенные маршруты (группы)
 	adminGroup := s.e.Group("/api/v1/admin")
 	adminGroup.Use(AuthMiddleware)
-	adminGroup.Use(AdminMiddleware)
 
-	adminGroup.POST("/users", CreateUser)
-	adminGroup.PUT("/users/:id", UpdateUser)
-	adminGroup.DELETE("/users/:id", DeleteUser)
+	adminGroup.POST("/users", CreateUser, RequirePermission("users:create"))
+	adminGroup.PUT("/users/:id", UpdateUser, RequirePermission("users:update"))
+	adminGroup.DELETE("/users/:id", DeleteUser, RequirePermission("users:delete"))
+	adminGroup.DELETE("/users/:id/sessions", RevokeUserSessions, RequirePermission("users:sessions"))
+	adminGroup.POST("/users/:id/unlock", UnlockUser, RequirePermission("users:unlock"))
+
+	adminGroup.GET("/roles", GetRoles, RequirePermission("roles:read"))
+	adminGroup.GET("/roles/:name", GetRole, RequirePermission("roles:read"))
+	adminGroup.POST("/roles", CreateRole, RequirePermission("roles:manage"))
+	adminGroup.PUT("/roles/:name", UpdateRole, RequirePermission("roles:manage"))
+	adminGroup.DELETE("/roles/:name", DeleteRole, RequirePermission("roles:manage"))
+	adminGroup.GET("/permissions", GetPermissions, RequirePermission("roles:read"))
 }
@@ -2,18 +2,38 @@ package api
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+	"work/tracing"
 
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
 type Server struct {
 	e *echo.Echo
 
 	user UserService
+
+	checks        []readinessCheck
+	stopping      atomic.Bool // /readyz отвечает 503 с начала остановки
+	shutdownDelay time.Duration
 }
 
 func New(service UserService) *Server {
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.HTTPErrorHandler = HTTPErrorHandler
+	// адрес клиента для защиты от перебора — адрес соединения; X-Forwarded-For
+	// учитывается только от доверенных прокси (SetTrustedProxies)
+	e.IPExtractor = echo.ExtractIPDirect()
+	// span запроса открывается первым, чтобы в записях лога был его trace_id
+	e.Use(otelecho.Middleware(tracing.ServiceName), RequestID, AccessLog, Metrics)
 
 	s := &Server{
 		e:    e,
@@ -22,13 +42,66 @@ func New(service UserService) *Server {
 
 	s.SetupRoutes()
 
+	// маршрут без описания в openapi.json — ошибка разработчика, сообщаем при запуске
+	missing, err := UndocumentedRoutes(e.Routes())
+	if err != nil {
+		slog.Error("не удалось разобрать спецификацию OpenAPI", "error", err)
+	}
+	for _, route := range missing {
+		slog.Warn("маршрут не описан в спецификации OpenAPI", "route", route)
+	}
+
 	return s
 }
 
+// ServeHTTP позволяет использовать сервер как http.Handler, например в httptest.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.e.ServeHTTP(w, r)
+}
+
 func (s *Server) Run(addr string) error {
 	return s.e.Start(addr)
 }
 
+// SetTrustedProxies задает сети прокси (CIDR), которым разрешено передавать адрес клиента
+// в X-Forwarded-For. Без них заголовок игнорируется: иначе клиент мог бы подставлять
+// новый адрес в каждом запросе и обходить ограничение попыток входа по IP.
+func (s *Server) SetTrustedProxies(cidrs []string) error {
+	if len(cidrs) == 0 {
+		s.e.IPExtractor = echo.ExtractIPDirect()
+		return nil
+	}
+	opts := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, echo.TrustIPRange(network))
+	}
+	s.e.IPExtractor = echo.ExtractIPFromXFFHeader(opts...)
+	return nil
+}
+
+// SetShutdownDelay задает, сколько Stop ждет после снятия с готовности, прежде чем
+// перестать принимать запросы: за это время балансировщик успевает увидеть 503 на /readyz.
+func (s *Server) SetShutdownDelay(d time.Duration) {
+	s.shutdownDelay = d
+}
+
+// Stop снимает сервер с готовности и дожидается завершения текущих запросов,
+// а затем фоновых задач, которые они запустили (отправка писем).
 func (s *Server) Stop(ctx context.Context) error {
-	return s.e.Shutdown(ctx)
+	s.stopping.Store(true)
+	if s.shutdownDelay > 0 {
+		select {
+		case <-time.After(s.shutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+	err := s.e.Shutdown(ctx)
+	if s.user != nil {
+		err = errors.Join(err, s.user.WaitBackground(ctx))
+	}
+	return err
 }
@@ -0,0 +1,325 @@
+// Package client типизированный Go-клиент API пользователей.
+//
+//	c := client.New("http://users:8080")
+//	if _, err := c.Login(ctx, "admin", password); err != nil { ... }
+//	page, err := c.ListUsers(ctx, models.UserListQuery{Limit: 100})
+//
+// Access токен обновляется автоматически по refresh токену незадолго до истечения
+// и при ответе 401; новые токены передаются в WithTokenHook.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"work/models"
+)
+
+// refreshBefore за сколько до истечения access токена он обновляется заранее.
+const refreshBefore = 30 * time.Second
+
+type Client struct {
+	baseURL  string
+	http     *http.Client
+	language string
+	hook     func(models.AuthResponse)
+
+	// refreshMu обновления идут по одному: сервер считает повторно предъявленный
+	// refresh токен кражей и отзывает все семейство
+	refreshMu sync.Mutex
+
+	mu           sync.Mutex
+	token        string
+	refreshToken string
+	expiresAt    time.Time
+}
+
+// Option настраивает Client при создании.
+type Option func(*Client)
+
+// WithHTTPClient задает HTTP-клиент (таймауты, транспорт). По умолчанию таймаут 30 секунд.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		c.http = h
+	}
+}
+
+// WithTokens задает ранее полученные токены, чтобы не выполнять вход заново.
+func WithTokens(token, refreshToken string) Option {
+	return func(c *Client) {
+		c.token, c.refreshToken = token, refreshToken
+	}
+}
+
+// WithTokenHook вызывается после каждого входа и обновления токенов, например чтобы сохранить их.
+func WithTokenHook(hook func(models.AuthResponse)) Option {
+	return func(c *Client) {
+		c.hook = hook
+	}
+}
+
+// WithLanguage задает Accept-Language для текстов ошибок.
+func WithLanguage(lang string) Option {
+	return func(c *Client) {
+		c.language = lang
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Login входит по логину и паролю. Если у пользователя включен второй фактор,
+// возвращается *MFARequiredError с токеном для LoginMFA.
+func (c *Client) Login(ctx context.Context, login, password string) (*models.AuthResponse, error) {
+	var raw json.RawMessage
+	err := c.do(ctx, http.MethodPost, "/api/v1/login", false,
+		models.LoginRequest{Login: login, Password: password}, &raw)
+	if err != nil {
+		return nil, err
+	}
+	var challenge models.MFAChallengeResponse
+	if err = json.Unmarshal(raw, &challenge); err == nil && challenge.MFARequired {
+		return nil, &MFARequiredError{Token: challenge.MFAToken}
+	}
+	var resp models.AuthResponse
+	if err = json.Unmarshal(raw, &resp); err != nil {
+		return nil, err
+	}
+	c.setTokens(&resp)
+	return &resp, nil
+}
+
+// LoginMFA второй шаг входа: код TOTP или код восстановления.
+func (c *Client) LoginMFA(ctx context.Context, mfaToken, code string) (*models.AuthResponse, error) {
+	var resp models.AuthResponse
+	err := c.do(ctx, http.MethodPost, "/api/v1/login/mfa", false,
+		models.MFALoginRequest{MFAToken: mfaToken, Code: code}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	c.setTokens(&resp)
+	return &resp, nil
+}
+
+// Refresh обменивает refresh токен на новую пару токенов.
+func (c *Client) Refresh(ctx context.Context) (*models.AuthResponse, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	return c.refresh(ctx)
+}
+
+// refresh выполняет обмен; вызывается под refreshMu.
+func (c *Client) refresh(ctx context.Context) (*models.AuthResponse, error) {
+	c.mu.Lock()
+	refreshToken := c.refreshToken
+	c.mu.Unlock()
+	if refreshToken == "" {
+		return nil, ErrNotLoggedIn
+	}
+	var resp models.AuthResponse
+	err := c.do(ctx, http.MethodPost, "/api/v1/token/refresh", false,
+		models.RefreshRequest{RefreshToken: refreshToken}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	c.setTokens(&resp)
+	return &resp, nil
+}
+
+// Logout отзывает текущие токены.
+func (c *Client) Logout(ctx context.Context) error {
+	c.mu.Lock()
+	refreshToken := c.refreshToken
+	c.mu.Unlock()
+	err := c.do(ctx, http.MethodPost, "/api/v1/logout", true, models.LogoutRequest{RefreshToken: refreshToken}, nil)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.token, c.refreshToken, c.expiresAt = "", "", time.Time{}
+	c.mu.Unlock()
+	return nil
+}
+
+// ListUsers возвращает страницу пользователей (право users:read); следующая — с q.Cursor = page.NextCursor.
+func (c *Client) ListUsers(ctx context.Context, q models.UserListQuery) (*models.UserPage, error) {
+	values := url.Values{}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	for key, value := range map[string]string{
+		"cursor": q.Cursor, "role": q.Role, "login_prefix": q.LoginPrefix, "sort": q.Sort,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	path := "/api/v1/users"
+	if len(values) > 0 {
+		path += "?" + values.Encode()
+	}
+	var page models.UserPage
+	if err := c.do(ctx, http.MethodGet, path, true, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// CreateUser создает пользователя (право users:create).
+func (c *Client) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	var created models.User
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/users", true, user, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateUser изменяет пользователя user.ID (право users:update). Пустой пароль не меняется.
+func (c *Client) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	var updated models.User
+	path := "/api/v1/admin/users/" + strconv.Itoa(user.ID)
+	if err := c.do(ctx, http.MethodPut, path, true, user, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteUser удаляет пользователя (право users:delete).
+func (c *Client) DeleteUser(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/admin/users/"+strconv.Itoa(id), true, nil, nil)
+}
+
+func (c *Client) setTokens(resp *models.AuthResponse) {
+	c.mu.Lock()
+	c.token, c.refreshToken = resp.Token, resp.RefreshToken
+	c.expiresAt = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	c.mu.Unlock()
+	if c.hook != nil {
+		c.hook(*resp)
+	}
+}
+
+// accessToken текущий access токен; обновляет его, если он скоро истечет.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	token, ok, err := c.currentToken()
+	if ok || err != nil {
+		return token, err
+	}
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	// пока ждали блокировку, токены мог обновить другой запрос
+	if token, ok, err = c.currentToken(); ok || err != nil {
+		return token, err
+	}
+	resp, err := c.refresh(ctx)
+	if err != nil {
+		return "", err
+	}
+	return resp.Token, nil
+}
+
+// currentToken текущий access токен; ok = false, если его нужно обновить.
+func (c *Client) currentToken() (token string, ok bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" && c.refreshToken == "" {
+		return "", false, ErrNotLoggedIn
+	}
+	if c.refreshToken != "" && (c.token == "" || !c.expiresAt.IsZero() && time.Until(c.expiresAt) < refreshBefore) {
+		return "", false, nil
+	}
+	return c.token, true, nil
+}
+
+// do выполняет запрос и разбирает ответ в out. auth — запрос требует access токен;
+// при 401 токен один раз обновляется и запрос повторяется.
+func (c *Client) do(ctx context.Context, method, path string, auth bool, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	if !auth {
+		return c.send(ctx, method, path, "", body, out)
+	}
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	err = c.send(ctx, method, path, token, body, out)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized {
+		return err
+	}
+	c.mu.Lock()
+	canRefresh := c.refreshToken != ""
+	// токен мог уже обновить другой запрос, получивший 401 раньше
+	if c.token == token {
+		c.token = ""
+	}
+	c.mu.Unlock()
+	if !canRefresh {
+		return err
+	}
+	if token, err = c.accessToken(ctx); err != nil {
+		return err
+	}
+	return c.send(ctx, method, path, token, body, out)
+}
+
+// send отправляет запрос; token — access токен для Authorization, пусто — без авторизации.
+func (c *Client) send(ctx context.Context, method, path, token string, body []byte, out any) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.language != "" {
+		req.Header.Set("Accept-Language", c.language)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newError(resp, data)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,342 @@
+package client_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"work/api"
+	"work/client"
+	"work/models"
+	"work/services"
+)
+
+const (
+	adminLogin    = "root"
+	adminPassword = "Correct-Horse-42"
+)
+
+// memStorage хранилище в памяти: ровно то, что нужно для входа, обновления токенов,
+// списка и удаления пользователей. Остальные методы services.Storage не вызываются.
+type memStorage struct {
+	services.Storage
+
+	mu       sync.Mutex
+	users    map[int]*models.User
+	tokens   []*models.RefreshToken
+	rotated  int  // успешные обмены refresh токена
+	reused   bool // предъявлен уже использованный refresh токен
+	lastID   int
+	permsFor map[string][]string
+}
+
+func newMemStorage(t *testing.T) *memStorage {
+	t.Helper()
+	hash, err := services.NewArgon2idHasher().Hash(adminPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &memStorage{
+		users:    map[int]*models.User{1: {ID: 1, Login: adminLogin, Password: hash, Role: services.RoleAdmin}},
+		permsFor: map[string][]string{services.RoleAdmin: {"users:delete"}},
+	}
+}
+
+type noTx struct{}
+
+func (noTx) Commit() error   { return nil }
+func (noTx) Rollback() error { return nil }
+
+func (m *memStorage) BeginTx(ctx context.Context, _ *sql.TxOptions) (services.Transaction, context.Context, error) {
+	return noTx{}, ctx, nil
+}
+
+func (m *memStorage) GetUserByLogin(_ context.Context, login string) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.users {
+		if u.Login == login {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, services.ErrUserNotFound
+}
+
+func (m *memStorage) GetUserById(_ context.Context, id int) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[id]
+	if !ok {
+		return nil, services.ErrUserNotFound
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (m *memStorage) DeleteUser(_ context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.users[id]; !ok {
+		return services.ErrUserNotFound
+	}
+	delete(m.users, id)
+	return nil
+}
+
+// ListUsers без фильтров и курсора: в тестах пользователей меньше одной страницы.
+func (m *memStorage) ListUsers(context.Context, models.UserListFilter) ([]models.AllUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	users := []models.AllUser{}
+	for _, u := range m.users {
+		users = append(users, models.AllUser{ID: u.ID, Login: u.Login, Role: u.Role})
+	}
+	return users, nil
+}
+
+func (m *memStorage) CountUsers(context.Context, models.UserListFilter) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.users), nil
+}
+
+func (m *memStorage) GetRole(_ context.Context, name string) (*models.Role, error) {
+	return &models.Role{Name: name, Permissions: m.permsFor[name]}, nil
+}
+
+func (m *memStorage) GetLoginAttempt(context.Context, string, string) (*models.LoginAttempt, error) {
+	return nil, nil
+}
+
+func (m *memStorage) RecordLoginFailure(context.Context, string, string, time.Duration) (int, error) {
+	return 1, nil
+}
+
+func (m *memStorage) ResetLoginAttempts(context.Context, string, string) error { return nil }
+
+func (m *memStorage) IsTokenRevoked(context.Context, string) (bool, error) { return false, nil }
+
+func (m *memStorage) GetTokensValidAfter(_ context.Context, userID int) (*time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.users[userID]; !ok {
+		return nil, nil
+	}
+	return &time.Time{}, nil
+}
+
+func (m *memStorage) CreateRefreshToken(_ context.Context, token *models.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastID++
+	cp := *token
+	cp.ID = m.lastID
+	m.tokens = append(m.tokens, &cp)
+	return nil
+}
+
+func (m *memStorage) GetRefreshTokenByHash(_ context.Context, hash string) (*models.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tokens {
+		if t.TokenHash == hash {
+			cp := *t
+			if t.UsedAt != nil || t.RevokedAt != nil {
+				m.reused = true
+			}
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memStorage) MarkRefreshTokenUsed(_ context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.ID == id {
+			t.UsedAt = &now
+			m.rotated++
+		}
+	}
+	return nil
+}
+
+func (m *memStorage) RevokeRefreshTokenFamily(_ context.Context, familyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.FamilyID == familyID {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *memStorage) stats() (rotated int, reused bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rotated, m.reused
+}
+
+// newServer поднимает настоящий api.Server поверх хранилища в памяти.
+func newServer(t *testing.T, accessTTL time.Duration) (*httptest.Server, *memStorage) {
+	t.Helper()
+	if err := services.LoadSigningKeys("", "", []byte("client-test-secret-0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	prevTTL := services.AccessTokenTTL
+	services.AccessTokenTTL = accessTTL
+	t.Cleanup(func() { services.AccessTokenTTL = prevTTL })
+
+	storage := newMemStorage(t)
+	service := services.NewUserService(storage)
+	api.SetService(service)
+	srv := httptest.NewServer(api.New(service))
+	t.Cleanup(srv.Close)
+	return srv, storage
+}
+
+func TestLogin(t *testing.T) {
+	srv, _ := newServer(t, time.Hour)
+	ctx := context.Background()
+	c := client.New(srv.URL)
+
+	if _, err := c.Login(ctx, adminLogin, "wrong-password"); err == nil {
+		t.Fatal("вход с неверным паролем прошел")
+	} else if apiErr := (*client.Error)(nil); !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized {
+		t.Fatalf("неверный пароль: %v", err)
+	}
+
+	resp, err := c.Login(ctx, adminLogin, adminPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" || resp.User.Login != adminLogin {
+		t.Fatalf("ответ входа: %+v", resp)
+	}
+	// запрос с токеном: пользователя 42 нет — 404, а не 401
+	var apiErr *client.Error
+	if err = c.DeleteUser(ctx, 42); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+}
+
+func TestListUsersRequiresPermission(t *testing.T) {
+	srv, storage := newServer(t, time.Hour)
+	ctx := context.Background()
+
+	c := client.New(srv.URL)
+	if _, err := c.ListUsers(ctx, models.UserListQuery{}); !errors.Is(err, client.ErrNotLoggedIn) {
+		t.Fatalf("без входа: %v", err)
+	}
+	if _, err := c.Login(ctx, adminLogin, adminPassword); err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *client.Error
+	if _, err := c.ListUsers(ctx, models.UserListQuery{}); !errors.As(err, &apiErr) || apiErr.Status != http.StatusForbidden {
+		t.Fatalf("без права users:read: %v", err)
+	}
+
+	storage.mu.Lock()
+	storage.permsFor[services.RoleAdmin] = append(storage.permsFor[services.RoleAdmin], "users:read")
+	storage.mu.Unlock()
+	if _, err := c.Login(ctx, adminLogin, adminPassword); err != nil {
+		t.Fatal(err)
+	}
+	page, err := c.ListUsers(ctx, models.UserListQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].Login != adminLogin {
+		t.Fatalf("страница: %+v", page)
+	}
+}
+
+func TestAutomaticRefresh(t *testing.T) {
+	// access токен живет меньше refreshBefore, поэтому обновляется перед запросом
+	srv, storage := newServer(t, 10*time.Second)
+	ctx := context.Background()
+	var hooked atomic.Int32
+	c := client.New(srv.URL, client.WithTokenHook(func(models.AuthResponse) { hooked.Add(1) }))
+	if _, err := c.Login(ctx, adminLogin, adminPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	var apiErr *client.Error
+	if err := c.DeleteUser(ctx, 42); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if rotated, _ := storage.stats(); rotated != 1 {
+		t.Fatalf("обменов refresh токена: %d, ожидался 1", rotated)
+	}
+	if hooked.Load() != 2 {
+		t.Fatalf("вызовов WithTokenHook: %d, ожидалось 2 (вход и обновление)", hooked.Load())
+	}
+}
+
+func TestRefreshOnUnauthorized(t *testing.T) {
+	srv, storage := newServer(t, time.Hour)
+	ctx := context.Background()
+	login, err := client.New(srv.URL).Login(ctx, adminLogin, adminPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// недействительный access токен: сервер отвечает 401, клиент обновляет токен и повторяет запрос
+	c := client.New(srv.URL, client.WithTokens("invalid", login.RefreshToken))
+	var apiErr *client.Error
+	if err = c.DeleteUser(ctx, 42); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if rotated, _ := storage.stats(); rotated != 1 {
+		t.Fatalf("обменов refresh токена: %d, ожидался 1", rotated)
+	}
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	srv, storage := newServer(t, time.Hour)
+	ctx := context.Background()
+	login, err := client.New(srv.URL).Login(ctx, adminLogin, adminPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// access токена нет: каждый запрос начинается с обновления, но обмен должен быть один,
+	// иначе сервер увидит повторно предъявленный refresh токен и отзовет семейство
+	c := client.New(srv.URL, client.WithTokens("", login.RefreshToken))
+	const workers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var apiErr *client.Error
+			if err := c.DeleteUser(ctx, 42); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("DeleteUser: %v", err)
+	}
+
+	rotated, reused := storage.stats()
+	if reused {
+		t.Fatal("refresh токен предъявлен повторно")
+	}
+	if rotated != 1 {
+		t.Fatalf("обменов refresh токена: %d, ожидался 1", rotated)
+	}
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"work/models"
+)
+
+// Категории ошибок API. Проверяются через errors.Is(err, client.ErrNotFound);
+// точная причина — в поле Code ошибки *Error.
+var (
+	ErrValidation   = errors.New("некорректные данные")
+	ErrUnauthorized = errors.New("требуется авторизация")
+	ErrForbidden    = errors.New("доступ запрещен")
+	ErrNotFound     = errors.New("не найдено")
+	ErrConflict     = errors.New("конфликт данных")
+	ErrLocked       = errors.New("вход временно заблокирован")
+	// ErrNotLoggedIn запрос требует токена, а Login еще не вызывался.
+	ErrNotLoggedIn = errors.New("клиент не авторизован")
+)
+
+// Error ответ API с ошибкой (application/problem+json).
+type Error struct {
+	models.Problem
+	// RetryAfter из заголовка Retry-After для 423 и 429.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Detail)
+	}
+	return fmt.Sprintf("%d %s", e.Status, e.Code)
+}
+
+// Is сопоставляет ошибку с категорией по HTTP-статусу.
+func (e *Error) Is(target error) bool {
+	switch e.Status {
+	case http.StatusBadRequest:
+		return target == ErrValidation
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusLocked, http.StatusTooManyRequests:
+		return target == ErrLocked
+	}
+	return false
+}
+
+// MFARequiredError вход по паролю прошел, нужен второй фактор: передайте Token в LoginMFA.
+type MFARequiredError struct {
+	Token string
+}
+
+func (e *MFARequiredError) Error() string {
+	return "требуется код второго фактора"
+}
+
+// newError разбирает ответ с ошибкой. Если тело не problem+json, заполняются только статус и код.
+func newError(resp *http.Response, body []byte) *Error {
+	apiErr := &Error{}
+	if err := json.Unmarshal(body, &apiErr.Problem); err != nil || apiErr.Status == 0 {
+		apiErr.Problem = models.Problem{
+			Status: resp.StatusCode,
+			Title:  http.StatusText(resp.StatusCode),
+			Code:   "http_" + strconv.Itoa(resp.StatusCode),
+		}
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(s) * time.Second
+	}
+	return apiErr
+}
@@ -2,57 +2,288 @@ package main
 
 import (
 	"context"
-	"embed"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"work/api"
+	"work/config"
+	"work/grpcapi"
+	"work/i18n"
+	"work/logging"
+	"work/mailers"
 	"work/services"
 	"work/storages/postgres"
+	"work/storages/postgres/migrations"
+	"work/tracing"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-const migrationsDir = "migrations"
+func main() {
+	args := os.Args[1:]
+	// server config print [-config file] [флаги] — итоговая конфигурация без секретов
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		cfg, err := config.Load(args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if err = cfg.Redacted().WriteYAML(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-//go:embed migrations/*.sql
-var MigrationsFS embed.FS
+	cfg, err := config.Load(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		os.Exit(2)
+	}
+	if err = setupLogger(cfg.Log); err != nil {
+		fatal("Failed to configure logger", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		File:        cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		fatal("Failed to configure tracing", err)
+	}
 
-func main() {
 	// восстановить миграцию
-	migrator := postgres.MustGetNewMigrator(MigrationsFS, migrationsDir)
+	migrator := postgres.MustGetNewMigrator(migrations.FS, ".")
 	// Инициализация БД
 
-	storage, err := postgres.NewConnection()
+	storage, err := postgres.NewConnection(cfg.Database.URL)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal("Failed to connect to database", err)
 	}
 	defer storage.Close()
 
 	//приминение миграции
 	err = migrator.ApplyMigrations(storage)
 	if err != nil {
-		panic(err)
+		fatal("Failed to apply migrations", err)
+	}
+	slog.Info("Миграции применены")
+
+	// ключи подписи JWT: каталог с PEM (RS256/EdDSA) или секрет (HS256)
+	if err = services.LoadSigningKeys(cfg.JWT.KeysDir, cfg.JWT.ActiveKID, []byte(cfg.JWT.Secret)); err != nil {
+		fatal("Failed to load signing keys", err)
 	}
-	log.Printf("Миграции применены!!")
+	services.AccessTokenTTL = cfg.JWT.AccessTokenTTL
+	services.RefreshTokenTTL = cfg.JWT.RefreshTokenTTL
 
-	userService := services.NewUserService(storage)
+	var opts []services.Option
+	// роли, для которых обязателен вход со вторым фактором, например admin
+	if len(cfg.Auth.MFARequiredRoles) > 0 {
+		opts = append(opts, services.WithMFARequiredRoles(cfg.Auth.MFARequiredRoles...))
+	}
+	opts = append(opts, services.WithRegistration(services.RegistrationConfig{
+		Enabled:             cfg.Auth.RegistrationEnabled,
+		RequireVerification: cfg.Auth.EmailVerificationRequired,
+		VerifyURL:           cfg.Auth.EmailVerifyURL,
+	}))
+	opts = append(opts, services.WithPasswordReset(services.PasswordResetConfig{
+		URL: cfg.Auth.PasswordResetURL,
+	}))
+	policy, err := services.NewPasswordPolicy(cfg.Password.MinLength, cfg.Password.MinClasses, cfg.Password.DenyListFile)
+	if err != nil {
+		fatal("Failed to configure password policy", err)
+	}
+	opts = append(opts, services.WithPasswordPolicy(policy))
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		fatal("Failed to configure mailer", err)
+	}
+	if mailer != nil {
+		opts = append(opts, services.WithMailer(mailer))
+	}
+	userService := services.NewUserService(storage, opts...)
+	if err = bootstrap(context.Background(), cfg, userService); err != nil {
+		fatal("Bootstrap failed", err)
+	}
 	api.SetService(userService)
+	api.SetTimeouts(cfg.Server.WriteTimeout, cfg.Server.ReadTimeout)
+
+	bundle, err := newMessages(cfg.I18n)
+	if err != nil {
+		fatal("Failed to load translations", err)
+	}
+	api.SetMessages(bundle)
+
+	// метрики отдаются на /metrics из реестра по умолчанию на внутреннем адресе server.metrics_addr
+	for _, register := range []func(prometheus.Registerer) error{
+		api.RegisterMetrics, services.RegisterMetrics, storage.RegisterMetrics,
+	} {
+		if err = register(prometheus.DefaultRegisterer); err != nil {
+			fatal("Failed to register metrics", err)
+		}
+	}
 	server := api.New(userService)
+	if err = server.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("Failed to configure trusted proxies", err)
+	}
+
+	// /readyz: соединение с базой, версия схемы и ключи подписи
+	expectedVersion, err := migrator.LatestVersion()
+	if err != nil {
+		fatal("Failed to read migrations", err)
+	}
+	server.AddReadinessCheck("database", storage.Ping)
+	server.AddReadinessCheck("migrations", func(ctx context.Context) error {
+		return storage.CheckMigrationVersion(ctx, expectedVersion)
+	})
+	server.AddReadinessCheck("signing_keys", func(context.Context) error {
+		if !services.Keys.Loaded() {
+			return errors.New("ключ подписи токенов не загружен")
+		}
+		return nil
+	})
+	// сколько /readyz отвечает 503 до закрытия порта при остановке
+	server.SetShutdownDelay(cfg.Server.ShutdownDelay)
+
+	grpcServer := grpcapi.New(userService)
+	metricsServer := api.NewMetricsServer()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	// устаревшие счетчики попыток входа и отозванные токены с истекшим сроком
+	go userService.RunCleanup(ctx, services.CleanupInterval)
 
+	// падение любого из серверов останавливает все
 	go func() {
-		log.Println("Starting server")
-		if err = server.Run(":8080"); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal(err)
+		slog.Info("Starting HTTP server", "addr", cfg.Server.HTTPAddr)
+		if err := server.Run(cfg.Server.HTTPAddr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server failed", "error", err)
 		}
+		stop()
 	}()
+	go func() {
+		slog.Info("Starting gRPC server", "addr", cfg.Server.GRPCAddr)
+		if err := grpcServer.Run(cfg.Server.GRPCAddr); err != nil {
+			slog.Error("gRPC server failed", "error", err)
+		}
+		stop()
+	}()
+	if cfg.Server.MetricsAddr != "" {
+		go func() {
+			slog.Info("Starting metrics server", "addr", cfg.Server.MetricsAddr)
+			if err := metricsServer.Run(cfg.Server.MetricsAddr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("Metrics server failed", "error", err)
+			}
+			stop()
+		}()
+	}
 
 	<-ctx.Done()
 
-	log.Println("Shutting down server")
-	if err = server.Stop(ctx); err != nil {
-		log.Fatal(err)
+	slog.Info("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err = server.Stop(shutdownCtx); err != nil {
+		slog.Error("HTTP server shutdown", "error", err)
+	}
+	if err = grpcServer.Stop(shutdownCtx); err != nil {
+		slog.Error("gRPC server shutdown", "error", err)
+	}
+	if err = metricsServer.Stop(shutdownCtx); err != nil {
+		slog.Error("Metrics server shutdown", "error", err)
+	}
+	if err = shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Tracing shutdown", "error", err)
+	}
+}
+
+// setupLogger делает логгер из конфигурации логгером по умолчанию,
+// в том числе для стандартного пакета log.
+func setupLogger(cfg config.LogConfig) error {
+	level, err := logging.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	logger, err := logging.New(os.Stdout, level, cfg.Format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// bootstrap проверяет учетные записи с паролем по умолчанию и создает первого администратора,
+// если в базе его нет. С такими учетными записями сервер не запускается, если это явно
+// не разрешено bootstrap.allow_default_credentials (вне production). Проверяется сам пароль,
+// а не хэш: после входа хэш SHA-256 из первой миграции заменяется на argon2id,
+// и миграция 010 такую учетную запись уже не узнает.
+func bootstrap(ctx context.Context, cfg *config.Config, userService *services.UserServiceDb) error {
+	logins, err := userService.DefaultCredentials(ctx)
+	if err != nil {
+		return err
+	}
+	if len(logins) > 0 {
+		if !cfg.Bootstrap.AllowDefaultCredentials {
+			return fmt.Errorf("учетные записи с паролем по умолчанию: %s; смените пароль (workctl user reset-password) или удалите их",
+				strings.Join(logins, ", "))
+		}
+		slog.Warn("Учетные записи с паролем по умолчанию разрешены bootstrap.allow_default_credentials", "logins", logins)
+	}
+
+	admin, generated, err := userService.BootstrapAdmin(ctx, cfg.Bootstrap.AdminLogin, cfg.Bootstrap.AdminPassword)
+	if err != nil || admin == nil {
+		return err
+	}
+	slog.Warn("Создан первый администратор, пароль нужно сменить при первом входе", "login", admin.Login)
+	if generated != "" {
+		// одноразовый пароль выводится только здесь и не попадает в структурированный лог
+		fmt.Fprintf(os.Stderr, "\nОдноразовый пароль администратора %s: %s\n\n", admin.Login, generated)
+	}
+	return nil
+}
+
+// newMailer выбирает способ отправки писем по mail.transport: smtp, file или log.
+func newMailer(cfg config.MailConfig) (services.Mailer, error) {
+	switch cfg.Transport {
+	case "smtp":
+		return mailers.NewSMTPMailer(cfg.SMTPAddr, cfg.From, cfg.SMTPUsername, cfg.SMTPPassword), nil
+	case "file":
+		return mailers.NewFileMailer(cfg.Dir, cfg.From)
+	case "log":
+		return mailers.LogMailer{}, nil
+	case "":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("неизвестный mail.transport %q", cfg.Transport)
+}
+
+// newMessages загружает встроенные каталоги сообщений и файлы *.json из i18n.locales_dir
+// (например, de.json добавляет немецкий язык без пересборки).
+func newMessages(cfg config.I18nConfig) (*i18n.Bundle, error) {
+	bundle, err := i18n.NewBundle("ru")
+	if err != nil {
+		return nil, err
+	}
+	if cfg.LocalesDir != "" {
+		if err = bundle.LoadDir(cfg.LocalesDir); err != nil {
+			return nil, err
+		}
+	}
+	// язык по умолчанию может быть добавлен только файлом из locales_dir
+	if err = bundle.SetDefault(cfg.DefaultLanguage); err != nil {
+		return nil, err
 	}
+	return bundle, nil
 }
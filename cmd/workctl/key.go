@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"work/services"
+)
+
+// kidLayout имя ключа при ротации: сортировка по имени совпадает с порядком создания,
+// поэтому сервер без jwt.active_kid подписывает последним ключом.
+const kidLayout = "2006-01-02T150405"
+
+// keyResult созданный ключ и удаленные при ротации старые ключи.
+type keyResult struct {
+	KID     string   `json:"kid"`
+	Alg     string   `json:"alg"`
+	File    string   `json:"file"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// keysDir каталог из -dir или jwt.keys_dir.
+func (a *app) keysDir(dir string) (string, error) {
+	if dir == "" {
+		dir = a.cfg.JWT.KeysDir
+	}
+	if dir == "" {
+		return "", usageError("не задан каталог ключей: -dir или jwt.keys_dir")
+	}
+	return dir, nil
+}
+
+// writeKey создает приватный ключ kid.pem; существующий файл не перезаписывается.
+func writeKey(dir, kid, alg string) (string, error) {
+	if kid == "" || strings.ContainsAny(kid, `/\`) || kid != filepath.Clean(kid) {
+		return "", usageError("некорректное имя ключа %q", kid)
+	}
+	data, err := services.GenerateKeyPEM(alg)
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, kid+".pem")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	return path, f.Close()
+}
+
+func (a *app) keyGenerate(_ context.Context, args []string) error {
+	fs := newFlagSet("key generate")
+	alg := fs.String("alg", "RS256", "алгоритм: RS256 или EdDSA")
+	dir := fs.String("dir", "", "каталог ключей (по умолчанию jwt.keys_dir)")
+	kid := fs.String("kid", time.Now().UTC().Format(kidLayout), "имя ключа (kid)")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	keysDir, err := a.keysDir(*dir)
+	if err != nil {
+		return err
+	}
+	path, err := writeKey(keysDir, *kid, *alg)
+	if err != nil {
+		return err
+	}
+	return a.out.message(keyResult{KID: *kid, Alg: *alg, File: path}, "Создан ключ %s (%s): %s", *kid, *alg, path)
+}
+
+// keyRotate создает новый ключ, который после перезапуска сервера станет активным.
+// Старые ключи остаются для проверки уже выпущенных токенов; -keep удаляет самые
+// старые из созданных ротацией, когда их больше N.
+func (a *app) keyRotate(_ context.Context, args []string) error {
+	fs := newFlagSet("key rotate")
+	alg := fs.String("alg", "RS256", "алгоритм: RS256 или EdDSA")
+	dir := fs.String("dir", "", "каталог ключей (по умолчанию jwt.keys_dir)")
+	keep := fs.Int("keep", 0, "сколько последних ключей оставить (0 — все)")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *keep == 1 || *keep < 0 {
+		// единственный ключ — новый: токены, подписанные прежним, перестали бы проходить проверку
+		return usageError("-keep должен быть 0 или не меньше 2")
+	}
+	keysDir, err := a.keysDir(*dir)
+	if err != nil {
+		return err
+	}
+	kid := time.Now().UTC().Format(kidLayout)
+	path, err := writeKey(keysDir, kid, *alg)
+	if err != nil {
+		return err
+	}
+	res := keyResult{KID: kid, Alg: *alg, File: path}
+
+	if *keep > 0 {
+		if res.Removed, err = pruneKeys(keysDir, *keep, a.cfg.JWT.ActiveKID); err != nil {
+			return err
+		}
+	}
+	if a.cfg.JWT.ActiveKID != "" {
+		fmt.Fprintf(os.Stderr, "внимание: jwt.active_kid = %q, новый ключ станет активным только после изменения настройки\n",
+			a.cfg.JWT.ActiveKID)
+	}
+	if len(res.Removed) > 0 {
+		return a.out.message(res, "Создан ключ %s (%s): %s; удалены: %s",
+			kid, *alg, path, strings.Join(res.Removed, ", "))
+	}
+	return a.out.message(res, "Создан ключ %s (%s): %s", kid, *alg, path)
+}
+
+// pruneKeys оставляет keep последних ключей, созданных ротацией (kid в формате kidLayout),
+// и возвращает удаленные kid. Ключи с другими именами, например добавленные вручную,
+// и настроенный jwt.active_kid не удаляются никогда.
+func pruneKeys(dir string, keep int, activeKID string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	var rotated []string
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		if _, err := time.Parse(kidLayout, kid); err == nil && kid != activeKID {
+			rotated = append(rotated, kid)
+		}
+	}
+	sort.Strings(rotated)
+
+	var removed []string
+	for _, kid := range rotated[:max(len(rotated)-keep, 0)] {
+		if err = os.Remove(filepath.Join(dir, kid+".pem")); err != nil {
+			return removed, err
+		}
+		removed = append(removed, kid)
+	}
+	return removed, nil
+}
+
+// keyInfo ключ каталога в выводе key list.
+type keyInfo struct {
+	KID     string `json:"kid"`
+	Alg     string `json:"alg"`
+	Private bool   `json:"private"`
+	Active  bool   `json:"active"`
+}
+
+func (a *app) keyList(_ context.Context, args []string) error {
+	fs := newFlagSet("key list")
+	dir := fs.String("dir", "", "каталог ключей (по умолчанию jwt.keys_dir)")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	keysDir, err := a.keysDir(*dir)
+	if err != nil {
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(keysDir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	// активный ключ выбирается так же, как при запуске сервера
+	active := ""
+	km := services.NewKeyManager()
+	loadErr := km.LoadDir(keysDir, a.cfg.JWT.ActiveKID)
+	if loadErr == nil {
+		active = km.ActiveKID()
+	}
+
+	keys := []keyInfo{}
+	var rows [][]string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := services.ParseKeyPEM(kid, data)
+		if err != nil {
+			return err
+		}
+		info := keyInfo{KID: kid, Alg: key.Method.Alg(), Private: key.Private != nil, Active: kid == active}
+		keys = append(keys, info)
+		rows = append(rows, []string{info.KID, info.Alg, yesNo(info.Private), yesNo(info.Active)})
+	}
+	if loadErr != nil {
+		fmt.Fprintln(os.Stderr, "внимание: сервер не сможет подписывать токены:", loadErr)
+	}
+	return a.out.table(keys, []string{"KID", "ALG", "PRIVATE", "ACTIVE"}, rows)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "да"
+	}
+	return "нет"
+}
@@ -0,0 +1,227 @@
+// Команда workctl — административная утилита: пользователи, миграции схемы
+// и ключи подписи JWT. Работает с той же базой и теми же сервисами, что и сервер,
+// и читает ту же конфигурацию (-config, CONFIG_FILE, переменные окружения).
+//
+//	workctl [-config файл] [-o table|json] <группа> <команда> [флаги]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"work/config"
+	"work/services"
+	"work/storages/postgres"
+)
+
+const usage = `Использование: workctl [-config файл] [-o table|json] <группа> <команда> [флаги]
+
+Пользователи:
+  user list [-role роль] [-login-prefix префикс]
+  user create -login логин [-role роль] [-email адрес] [-password пароль]
+  user set-role -login логин -role роль
+  user reset-password -login логин [-password пароль]
+  user delete -login логин
+
+Миграции (встроенные в бинарник):
+  migrate up
+  migrate down [-steps N]
+  migrate goto ВЕРСИЯ
+  migrate version
+  migrate force ВЕРСИЯ
+
+Ключи подписи JWT (каталог jwt.keys_dir или -dir):
+  key generate [-alg RS256|EdDSA] [-dir каталог] [-kid имя]
+  key rotate [-alg RS256|EdDSA] [-dir каталог] [-keep N]
+      -keep удаляет только старые ключи ротации (kid вида 2026-01-02T150405),
+      но не jwt.active_kid и не ключи с другими именами
+  key list [-dir каталог]
+
+Если -password не задан, пароль генерируется, печатается один раз
+и должен быть сменен при первом входе.
+`
+
+// app общие для всех команд настройки и ленивые зависимости.
+type app struct {
+	cfg *config.Config
+	out *printer
+
+	storage *postgres.Storage
+}
+
+func main() {
+	fs := flag.NewFlagSet("workctl", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	configFile := fs.String("config", os.Getenv(config.EnvConfigFile), "файл конфигурации YAML или TOML")
+	format := fs.String("o", "table", "формат вывода: table или json")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "неизвестный формат вывода %q: table или json\n", *format)
+		os.Exit(2)
+	}
+	args := fs.Args()
+	if len(args) < 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var cfgArgs []string
+	if *configFile != "" {
+		cfgArgs = []string{"-config", *configFile}
+	}
+	cfg, err := config.Parse(cfgArgs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		os.Exit(2)
+	}
+
+	a := &app{cfg: cfg, out: &printer{w: os.Stdout, json: *format == "json"}}
+	defer a.close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	commands := map[string]map[string]func(context.Context, []string) error{
+		"user": {
+			"list":           a.userList,
+			"create":         a.userCreate,
+			"set-role":       a.userSetRole,
+			"reset-password": a.userResetPassword,
+			"delete":         a.userDelete,
+		},
+		"migrate": {
+			"up":      a.migrateUp,
+			"down":    a.migrateDown,
+			"goto":    a.migrateGoto,
+			"version": a.migrateVersion,
+			"force":   a.migrateForce,
+		},
+		"key": {
+			"generate": a.keyGenerate,
+			"rotate":   a.keyRotate,
+			"list":     a.keyList,
+		},
+	}
+	run, ok := commands[args[0]][args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "неизвестная команда %q\n\n", strings.Join(args[:2], " "))
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err = run(ctx, args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "ошибка:", describe(err))
+		code := 1
+		if errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr)
+			fs.Usage()
+			code = 2
+		}
+		a.close()
+		os.Exit(code)
+	}
+}
+
+// errUsage неверные аргументы команды; код выхода 2, как у ошибок флагов.
+var errUsage = errors.New("неверные аргументы")
+
+func usageError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// describe дополняет сообщение доменной ошибки ошибками полей.
+func describe(err error) string {
+	var e *services.Error
+	if !errors.As(err, &e) || len(e.Fields) == 0 {
+		return err.Error()
+	}
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return e.Message + " (" + strings.Join(parts, "; ") + ")"
+}
+
+// db подключается к базе при первом обращении: командам key база не нужна.
+func (a *app) db() (*postgres.Storage, error) {
+	if a.storage != nil {
+		return a.storage, nil
+	}
+	if a.cfg.Database.URL == "" {
+		return nil, errors.New("database.url: не задан")
+	}
+	storage, err := postgres.NewConnection(a.cfg.Database.URL)
+	if err != nil {
+		return nil, err
+	}
+	a.storage = storage
+	return storage, nil
+}
+
+func (a *app) close() {
+	if a.storage != nil {
+		a.storage.Close()
+		a.storage = nil
+	}
+}
+
+// newFlagSet флаги подкоманды; ошибки разбора возвращаются, а не завершают процесс.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseFlags разбирает флаги подкоманды и проверяет число позиционных аргументов.
+func parseFlags(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		return usageError("%s: %v", fs.Name(), err)
+	}
+	if fs.NArg() != nargs {
+		return usageError("%s: ожидается позиционных аргументов: %d", fs.Name(), nargs)
+	}
+	return nil
+}
+
+// printer выводит результат команды таблицей или JSON (-o json) для скриптов.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+// table печатает строки rows под заголовком header; в JSON выводится value.
+func (p *printer) table(value any, header []string, rows [][]string) error {
+	if p.json {
+		return p.value(value)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// message печатает сообщение о выполненном действии; в JSON выводится value.
+func (p *printer) message(value any, format string, args ...any) error {
+	if p.json {
+		return p.value(value)
+	}
+	_, err := fmt.Fprintf(p.w, format+"\n", args...)
+	return err
+}
+
+func (p *printer) value(value any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value)
+}
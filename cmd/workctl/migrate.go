@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"work/storages/postgres"
+	"work/storages/postgres/migrations"
+)
+
+// migrator миграции, встроенные в бинарник: те же, что применяет сервер при запуске.
+func (a *app) migrator() (*postgres.Migrator, *postgres.Storage, error) {
+	storage, err := a.db()
+	if err != nil {
+		return nil, nil, err
+	}
+	return postgres.MustGetNewMigrator(migrations.FS, "."), storage, nil
+}
+
+// schemaVersion состояние схемы после команды.
+type schemaVersion struct {
+	Version uint `json:"version"`
+	Dirty   bool `json:"dirty"`
+	Latest  uint `json:"latest"`
+	Applied bool `json:"applied"` // false, если миграции еще не применялись
+}
+
+func (a *app) printVersion(m *postgres.Migrator, storage *postgres.Storage) error {
+	version, dirty, ok, err := m.Version(storage)
+	if err != nil {
+		return err
+	}
+	latest, err := m.LatestVersion()
+	if err != nil {
+		return err
+	}
+	res := schemaVersion{Version: version, Dirty: dirty, Latest: latest, Applied: ok}
+	current := "нет"
+	if ok {
+		current = strconv.FormatUint(uint64(version), 10)
+	}
+	return a.out.table(res, []string{"VERSION", "DIRTY", "LATEST"},
+		[][]string{{current, yesNo(dirty), strconv.FormatUint(uint64(latest), 10)}})
+}
+
+func (a *app) migrateUp(_ context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("migrate up"), args, 0); err != nil {
+		return err
+	}
+	m, storage, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	if err = m.ApplyMigrations(storage); err != nil {
+		return err
+	}
+	return a.printVersion(m, storage)
+}
+
+func (a *app) migrateDown(_ context.Context, args []string) error {
+	fs := newFlagSet("migrate down")
+	steps := fs.Int("steps", 1, "сколько миграций откатить")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return usageError("-steps должен быть больше нуля")
+	}
+	m, storage, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	if err = m.Down(storage, *steps); err != nil {
+		return err
+	}
+	return a.printVersion(m, storage)
+}
+
+func (a *app) migrateGoto(_ context.Context, args []string) error {
+	fs := newFlagSet("migrate goto")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	version, err := strconv.ParseUint(fs.Arg(0), 10, 32)
+	if err != nil {
+		return usageError("migrate goto: некорректная версия %q", fs.Arg(0))
+	}
+	m, storage, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	if err = m.Goto(storage, uint(version)); err != nil {
+		return err
+	}
+	return a.printVersion(m, storage)
+}
+
+func (a *app) migrateVersion(_ context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("migrate version"), args, 0); err != nil {
+		return err
+	}
+	m, storage, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	return a.printVersion(m, storage)
+}
+
+func (a *app) migrateForce(_ context.Context, args []string) error {
+	fs := newFlagSet("migrate force")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	// -1 очищает версию, как в golang-migrate
+	version, err := strconv.Atoi(fs.Arg(0))
+	if err != nil || version < -1 {
+		return usageError("migrate force: некорректная версия %q", fs.Arg(0))
+	}
+	m, storage, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	if err = m.Force(storage, version); err != nil {
+		return err
+	}
+	return a.printVersion(m, storage)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"work/models"
+	"work/services"
+	"work/storages/postgres"
+)
+
+// userService сервис пользователей с политикой паролей из конфигурации,
+// чтобы пароли из утилиты проверялись так же, как через API.
+func (a *app) userService() (*services.UserServiceDb, *postgres.Storage, error) {
+	storage, err := a.db()
+	if err != nil {
+		return nil, nil, err
+	}
+	policy, err := services.NewPasswordPolicy(a.cfg.Password.MinLength, a.cfg.Password.MinClasses, a.cfg.Password.DenyListFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return services.NewUserService(storage, services.WithPasswordPolicy(policy)), storage, nil
+}
+
+// findUser пользователь по логину из обязательного флага -login.
+func (a *app) findUser(ctx context.Context, storage *postgres.Storage, login string) (*models.User, error) {
+	if login == "" {
+		return nil, usageError("не задан -login")
+	}
+	return storage.GetUserByLogin(ctx, login)
+}
+
+func (a *app) userList(ctx context.Context, args []string) error {
+	fs := newFlagSet("user list")
+	role := fs.String("role", "", "только пользователи с ролью")
+	prefix := fs.String("login-prefix", "", "только логины с префиксом")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	service, _, err := a.userService()
+	if err != nil {
+		return err
+	}
+
+	users := []models.AllUser{}
+	q := models.UserListQuery{Limit: services.MaxPageSize, Role: *role, LoginPrefix: *prefix}
+	for {
+		page, err := service.ListUsers(ctx, q)
+		if err != nil {
+			return err
+		}
+		users = append(users, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+
+	rows := make([][]string, 0, len(users))
+	for _, u := range users {
+		rows = append(rows, []string{strconv.Itoa(u.ID), u.Login, u.Role})
+	}
+	return a.out.table(users, []string{"ID", "LOGIN", "ROLE"}, rows)
+}
+
+// userResult результат команд user; пароль выводится, только если сгенерирован.
+type userResult struct {
+	ID       int    `json:"id"`
+	Login    string `json:"login"`
+	Role     string `json:"role"`
+	Password string `json:"password,omitempty"`
+}
+
+func (a *app) userCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("user create")
+	login := fs.String("login", "", "логин")
+	role := fs.String("role", services.RoleUser, "роль")
+	email := fs.String("email", "", "адрес почты (необязательно)")
+	password := fs.String("password", "", "пароль; если не задан, генерируется")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *login == "" {
+		return usageError("не задан -login")
+	}
+	service, _, err := a.userService()
+	if err != nil {
+		return err
+	}
+
+	generated := ""
+	if *password == "" {
+		if generated, err = service.GeneratePassword(); err != nil {
+			return err
+		}
+		*password = generated
+	}
+	// сгенерированный пароль нужно сменить при первом входе
+	user := &models.User{Login: *login, Password: *password, Role: *role, MustChangePassword: generated != ""}
+	if *email != "" {
+		user.Email = email
+	}
+	if err = service.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	res := userResult{ID: user.ID, Login: user.Login, Role: user.Role, Password: generated}
+	if generated != "" {
+		return a.out.message(res, "Создан пользователь %s (id %d, роль %s), пароль: %s", user.Login, user.ID, user.Role, generated)
+	}
+	return a.out.message(res, "Создан пользователь %s (id %d, роль %s)", user.Login, user.ID, user.Role)
+}
+
+func (a *app) userSetRole(ctx context.Context, args []string) error {
+	fs := newFlagSet("user set-role")
+	login := fs.String("login", "", "логин")
+	role := fs.String("role", "", "новая роль")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *role == "" {
+		return usageError("не задан -role")
+	}
+	service, storage, err := a.userService()
+	if err != nil {
+		return err
+	}
+	user, err := a.findUser(ctx, storage, *login)
+	if err != nil {
+		return err
+	}
+	// смена роли отзывает выпущенные токены пользователя
+	if err = service.UpdateUser(ctx, &models.User{ID: user.ID, Role: *role}); err != nil {
+		return err
+	}
+	res := userResult{ID: user.ID, Login: user.Login, Role: *role}
+	return a.out.message(res, "Пользователю %s назначена роль %s", user.Login, *role)
+}
+
+func (a *app) userResetPassword(ctx context.Context, args []string) error {
+	fs := newFlagSet("user reset-password")
+	login := fs.String("login", "", "логин")
+	password := fs.String("password", "", "новый пароль; если не задан, генерируется")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	service, storage, err := a.userService()
+	if err != nil {
+		return err
+	}
+	user, err := a.findUser(ctx, storage, *login)
+	if err != nil {
+		return err
+	}
+
+	generated := ""
+	if *password == "" {
+		if generated, err = service.GeneratePassword(); err != nil {
+			return err
+		}
+		*password = generated
+	}
+	// новый пароль проверяется политикой, все сессии пользователя завершаются;
+	// сгенерированный пароль нужно сменить при первом входе
+	err = service.UpdateUser(ctx, &models.User{
+		ID: user.ID, Login: user.Login, Password: *password, MustChangePassword: generated != "",
+	})
+	if err != nil {
+		return err
+	}
+
+	res := userResult{ID: user.ID, Login: user.Login, Role: user.Role, Password: generated}
+	if generated != "" {
+		return a.out.message(res, "Пароль пользователя %s изменен, новый пароль: %s", user.Login, generated)
+	}
+	return a.out.message(res, "Пароль пользователя %s изменен", user.Login)
+}
+
+func (a *app) userDelete(ctx context.Context, args []string) error {
+	fs := newFlagSet("user delete")
+	login := fs.String("login", "", "логин")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	service, storage, err := a.userService()
+	if err != nil {
+		return err
+	}
+	user, err := a.findUser(ctx, storage, *login)
+	if err != nil {
+		return err
+	}
+	if err = service.DeleteUser(ctx, user.ID); err != nil {
+		return err
+	}
+	res := userResult{ID: user.ID, Login: user.Login, Role: user.Role}
+	return a.out.message(res, "Пользователь %s удален", user.Login)
+}
@@ -0,0 +1,219 @@
+// Package config настройки сервера в одной типизированной структуре.
+//
+// Значения собираются по возрастанию приоритета: значения по умолчанию, файл
+// YAML или TOML (-config или CONFIG_FILE), переменные окружения, флаги командной
+// строки. Для любой переменной можно задать NAME_FILE — путь к файлу со значением
+// (Docker secrets). Имена переменных и флагов заданы тегами env и yaml полей:
+// поле JWT.Secret читается из jwt.secret в файле, JWT_SECRET и -jwt.secret.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// MinSecretLength минимальная длина JWT_SECRET в байтах (HS256).
+const MinSecretLength = 32
+
+// MaxPasswordLength наибольшая длина пароля, которую принимает сервис.
+const MaxPasswordLength = 128
+
+// Окружения server.environment. Пока в базе есть учетная запись с общеизвестным
+// паролем, сервер не запускается; разрешить это (bootstrap.allow_default_credentials)
+// можно только вне production.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	JWT       JWTConfig       `yaml:"jwt" toml:"jwt"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	Bootstrap BootstrapConfig `yaml:"bootstrap" toml:"bootstrap"`
+	Password  PasswordConfig  `yaml:"password" toml:"password"`
+	Mail      MailConfig      `yaml:"mail" toml:"mail"`
+	I18n      I18nConfig      `yaml:"i18n" toml:"i18n"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+}
+
+type ServerConfig struct {
+	Environment     string        `yaml:"environment" toml:"environment" env:"APP_ENV"` // development или production
+	HTTPAddr        string        `yaml:"http_addr" toml:"http_addr" env:"HTTP_ADDR"`
+	GRPCAddr        string        `yaml:"grpc_addr" toml:"grpc_addr" env:"GRPC_ADDR"`
+	MetricsAddr     string        `yaml:"metrics_addr" toml:"metrics_addr" env:"METRICS_ADDR"`    // внутренний адрес /metrics; пусто — не отдавать
+	ReadTimeout     time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"READ_TIMEOUT"`    // на запросы чтения
+	WriteTimeout    time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"WRITE_TIMEOUT"` // на запросы изменения
+	ShutdownDelay   time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" env:"SHUTDOWN_DELAY"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// сети прокси (CIDR), от которых принимается X-Forwarded-For; пусто — адрес соединения
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+type DatabaseConfig struct {
+	URL string `yaml:"url" toml:"url" env:"DATABASE_URL" secret:"url"`
+}
+
+type JWTConfig struct {
+	Secret          string        `yaml:"secret" toml:"secret" env:"JWT_SECRET" secret:"true"`
+	KeysDir         string        `yaml:"keys_dir" toml:"keys_dir" env:"JWT_KEYS_DIR"` // PEM-ключи RS256/EdDSA вместо Secret
+	ActiveKID       string        `yaml:"active_kid" toml:"active_kid" env:"JWT_ACTIVE_KID"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" toml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" toml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+}
+
+type AuthConfig struct {
+	MFARequiredRoles          []string `yaml:"mfa_required_roles" toml:"mfa_required_roles" env:"MFA_REQUIRED_ROLES"`
+	RegistrationEnabled       bool     `yaml:"registration_enabled" toml:"registration_enabled" env:"REGISTRATION_ENABLED"`
+	EmailVerificationRequired bool     `yaml:"email_verification_required" toml:"email_verification_required" env:"EMAIL_VERIFICATION_REQUIRED"`
+	EmailVerifyURL            string   `yaml:"email_verify_url" toml:"email_verify_url" env:"EMAIL_VERIFY_URL"`
+	PasswordResetURL          string   `yaml:"password_reset_url" toml:"password_reset_url" env:"PASSWORD_RESET_URL"`
+}
+
+// BootstrapConfig первый администратор; создается при запуске, если в базе нет ни одного
+// пользователя с ролью admin. Без пароля генерируется одноразовый и выводится один раз.
+type BootstrapConfig struct {
+	AdminLogin    string `yaml:"admin_login" toml:"admin_login" env:"BOOTSTRAP_ADMIN_LOGIN"`
+	AdminPassword string `yaml:"admin_password" toml:"admin_password" env:"BOOTSTRAP_ADMIN_PASSWORD" secret:"true"`
+	// AllowDefaultCredentials запускать сервер, хотя в базе есть учетная запись
+	// с общеизвестным паролем (admin/admin); только для локальной разработки
+	AllowDefaultCredentials bool `yaml:"allow_default_credentials" toml:"allow_default_credentials" env:"BOOTSTRAP_ALLOW_DEFAULT_CREDENTIALS"`
+}
+
+type PasswordConfig struct {
+	MinLength    int    `yaml:"min_length" toml:"min_length" env:"PASSWORD_MIN_LENGTH"`
+	MinClasses   int    `yaml:"min_classes" toml:"min_classes" env:"PASSWORD_MIN_CLASSES"`
+	DenyListFile string `yaml:"denylist_file" toml:"denylist_file" env:"PASSWORD_DENYLIST_FILE"`
+}
+
+type MailConfig struct {
+	Transport    string `yaml:"transport" toml:"transport" env:"MAIL_TRANSPORT"` // smtp, file, log или пусто
+	From         string `yaml:"from" toml:"from" env:"MAIL_FROM"`
+	SMTPAddr     string `yaml:"smtp_addr" toml:"smtp_addr" env:"SMTP_ADDR"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	Dir          string `yaml:"dir" toml:"dir" env:"MAIL_DIR"`
+}
+
+type I18nConfig struct {
+	DefaultLanguage string `yaml:"default_language" toml:"default_language" env:"DEFAULT_LANGUAGE"`
+	LocalesDir      string `yaml:"locales_dir" toml:"locales_dir" env:"LOCALES_DIR"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"` // адрес OTLP — в OTEL_EXPORTER_OTLP_*
+	File        string  `yaml:"file" toml:"file" env:"TRACING_FILE"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// Default значения, которые действовали до появления файла конфигурации.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Environment:     EnvDevelopment,
+			HTTPAddr:        ":8080",
+			GRPCAddr:        ":9090",
+			MetricsAddr:     ":9100",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    5 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+		},
+		Database: DatabaseConfig{
+			URL: "postgresql://postgres:postgres@db:5432/workspace?sslmode=disable",
+		},
+		JWT: JWTConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		Bootstrap: BootstrapConfig{AdminLogin: "admin"},
+		Password:  PasswordConfig{MinLength: 8, MinClasses: 2},
+		Mail:      MailConfig{From: "noreply@localhost"},
+		I18n:      I18nConfig{DefaultLanguage: "ru"},
+		Log:       LogConfig{Level: "info", Format: "json"},
+		Tracing:   TracingConfig{SampleRatio: 1},
+	}
+}
+
+// Validate проверяет конфигурацию целиком и возвращает все найденные ошибки сразу.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Environment == EnvDevelopment || c.Server.Environment == EnvProduction,
+		"server.environment: неизвестное значение %q (development, production)", c.Server.Environment)
+	check(c.Server.HTTPAddr != "", "server.http_addr: не задан")
+	check(c.Server.GRPCAddr != "", "server.grpc_addr: не задан")
+	check(c.Server.MetricsAddr == "" || (c.Server.MetricsAddr != c.Server.HTTPAddr && c.Server.MetricsAddr != c.Server.GRPCAddr),
+		"server.metrics_addr: должен отличаться от http_addr и grpc_addr")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout: должен быть больше нуля")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout: должен быть больше нуля")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay: не может быть отрицательным")
+	check(c.Server.ShutdownTimeout > c.Server.ShutdownDelay,
+		"server.shutdown_timeout: должен быть больше shutdown_delay")
+
+	for _, cidr := range c.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(cidr)
+		check(err == nil, "server.trusted_proxies: %q не является сетью CIDR", cidr)
+	}
+
+	check(c.Database.URL != "", "database.url: не задан")
+
+	if c.JWT.KeysDir == "" {
+		check(len(c.JWT.Secret) >= MinSecretLength,
+			"jwt.secret: нужен секрет не короче %d байт или каталог ключей jwt.keys_dir", MinSecretLength)
+	}
+	check(c.JWT.AccessTokenTTL > 0, "jwt.access_token_ttl: должен быть больше нуля")
+	check(c.JWT.RefreshTokenTTL > c.JWT.AccessTokenTTL,
+		"jwt.refresh_token_ttl: должен быть больше access_token_ttl")
+
+	check(c.Bootstrap.AdminLogin != "", "bootstrap.admin_login: не задан")
+	check(!c.Bootstrap.AllowDefaultCredentials || c.Server.Environment != EnvProduction,
+		"bootstrap.allow_default_credentials: нельзя включать в production")
+
+	check(c.Password.MinLength >= 1 && c.Password.MinLength <= MaxPasswordLength,
+		"password.min_length: от 1 до %d", MaxPasswordLength)
+	check(c.Password.MinClasses >= 1 && c.Password.MinClasses <= 4, "password.min_classes: от 1 до 4")
+
+	switch c.Mail.Transport {
+	case "", "log":
+	case "smtp":
+		check(c.Mail.SMTPAddr != "", "mail.smtp_addr: обязателен для transport smtp")
+	case "file":
+		check(c.Mail.Dir != "", "mail.dir: обязателен для transport file")
+	default:
+		check(false, "mail.transport: неизвестное значение %q (smtp, file, log)", c.Mail.Transport)
+	}
+
+	check(c.I18n.DefaultLanguage != "", "i18n.default_language: не задан")
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "log.level: неизвестное значение %q (debug, info, warn, error)", c.Log.Level)
+	}
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log.format: json или text")
+
+	switch c.Tracing.Exporter {
+	case "", "none", "stderr", "stdout", "otlp":
+	case "file":
+		check(c.Tracing.File != "", "tracing.file: обязателен для exporter file")
+	default:
+		check(false, "tracing.exporter: неизвестное значение %q (none, stderr, file, otlp)", c.Tracing.Exporter)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio: от 0 до 1")
+
+	return errors.Join(errs...)
+}
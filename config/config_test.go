@@ -0,0 +1,219 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unsetEnv убирает переменные на время теста: t.Setenv восстановит прежние значения.
+func unsetEnv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParsePrecedence(t *testing.T) {
+	const yamlFile = "server:\n  http_addr: \":8081\"\njwt:\n  secret: from-file\n"
+	const tomlFile = "[server]\nhttp_addr = \":8081\"\n[jwt]\nsecret = \"from-file\"\n"
+	tests := []struct {
+		name       string
+		fileName   string
+		file       string
+		env        map[string]string
+		secretFile string // содержимое JWT_SECRET_FILE
+		args       []string
+		wantAddr   string
+		wantSecret string
+	}{
+		{name: "по умолчанию", wantAddr: ":8080"},
+		{name: "файл YAML", fileName: "config.yaml", file: yamlFile, wantAddr: ":8081", wantSecret: "from-file"},
+		{name: "файл TOML", fileName: "config.toml", file: tomlFile, wantAddr: ":8081", wantSecret: "from-file"},
+		{name: "окружение важнее файла", fileName: "config.yaml", file: yamlFile,
+			env:      map[string]string{"HTTP_ADDR": ":8082", "JWT_SECRET": "from-env"},
+			wantAddr: ":8082", wantSecret: "from-env"},
+		{name: "_FILE важнее файла", fileName: "config.yaml", file: yamlFile, secretFile: "from-secret-file\n",
+			wantAddr: ":8081", wantSecret: "from-secret-file"},
+		{name: "флаги важнее окружения", fileName: "config.yaml", file: yamlFile,
+			env:        map[string]string{"HTTP_ADDR": ":8082"},
+			secretFile: "from-secret-file",
+			args:       []string{"-server.http-addr", ":8083", "-jwt.secret=from-flag"},
+			wantAddr:   ":8083", wantSecret: "from-flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetEnv(t, EnvConfigFile, "HTTP_ADDR", "JWT_SECRET", "JWT_SECRET_FILE", "READ_TIMEOUT")
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, tt.fileName, tt.file)}, args...)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if tt.secretFile != "" {
+				t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt_secret", tt.secretFile))
+			}
+
+			cfg, err := Parse(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Server.HTTPAddr != tt.wantAddr || cfg.JWT.Secret != tt.wantSecret {
+				t.Fatalf("http_addr %q, jwt.secret %q; ожидалось %q, %q",
+					cfg.Server.HTTPAddr, cfg.JWT.Secret, tt.wantAddr, tt.wantSecret)
+			}
+			// параметры, которые нигде не заданы, остаются по умолчанию
+			if cfg.Server.ReadTimeout != Default().Server.ReadTimeout {
+				t.Fatalf("read_timeout %s", cfg.Server.ReadTimeout)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		file     string
+		env      map[string]string
+		args     []string
+		want     string
+	}{
+		{name: "неизвестный ключ YAML", fileName: "config.yaml", file: "server:\n  http_adr: \":8081\"\n", want: "http_adr"},
+		{name: "неизвестный ключ TOML", fileName: "config.toml", file: "[server]\nhttp_adr = \":8081\"\n", want: "http_adr"},
+		{name: "неизвестный формат", fileName: "config.json", file: "{}", want: ".toml"},
+		{name: "переменная и _FILE одновременно",
+			env:  map[string]string{"JWT_SECRET": "a", "JWT_SECRET_FILE": "/nonexistent"},
+			want: "JWT_SECRET и JWT_SECRET_FILE"},
+		{name: "некорректная длительность", env: map[string]string{"READ_TIMEOUT": "10"}, want: "READ_TIMEOUT"},
+		{name: "некорректный флаг", args: []string{"-server.read-timeout", "soon"}, want: "-server.read-timeout"},
+		{name: "лишние аргументы", args: []string{"serve"}, want: "лишние аргументы"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetEnv(t, EnvConfigFile, "HTTP_ADDR", "JWT_SECRET", "JWT_SECRET_FILE", "READ_TIMEOUT")
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, tt.fileName, tt.file)}, args...)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			_, err := Parse(args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ошибка %v, ожидалось упоминание %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*Config)
+		check func(*Config) string // значение, которое должно получиться
+		want  string
+	}{
+		{"пароль в адресе базы",
+			func(c *Config) { c.Database.URL = "postgresql://app:s3cret@db:5432/work?sslmode=disable" },
+			func(c *Config) string { return c.Database.URL },
+			"postgresql://app:xxxxx@db:5432/work?sslmode=disable"},
+		{"адрес базы без пароля не меняется",
+			func(c *Config) { c.Database.URL = "postgresql://app@db:5432/work" },
+			func(c *Config) string { return c.Database.URL },
+			"postgresql://app@db:5432/work"},
+		{"строка подключения не URL скрывается целиком",
+			func(c *Config) { c.Database.URL = "host=db password=s3cret" },
+			func(c *Config) string { return c.Database.URL },
+			redacted},
+		{"секрет JWT",
+			func(c *Config) { c.JWT.Secret = strings.Repeat("k", MinSecretLength) },
+			func(c *Config) string { return c.JWT.Secret },
+			redacted},
+		{"пароль администратора",
+			func(c *Config) { c.Bootstrap.AdminPassword = "Correct-Horse-42" },
+			func(c *Config) string { return c.Bootstrap.AdminPassword },
+			redacted},
+		{"пароль SMTP",
+			func(c *Config) { c.Mail.SMTPPassword = "smtp-pass" },
+			func(c *Config) string { return c.Mail.SMTPPassword },
+			redacted},
+		{"пустой секрет остается пустым",
+			func(c *Config) { c.JWT.Secret = "" },
+			func(c *Config) string { return c.JWT.Secret },
+			""},
+		{"обычные параметры не скрываются",
+			func(c *Config) { c.Mail.SMTPUsername = "mailer" },
+			func(c *Config) string { return c.Mail.SMTPUsername },
+			"mailer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.setup(cfg)
+			before := tt.check(cfg)
+			if got := tt.check(cfg.Redacted()); got != tt.want {
+				t.Fatalf("получено %q, ожидалось %q", got, tt.want)
+			}
+			if tt.check(cfg) != before {
+				t.Fatal("Redacted изменил исходную конфигурацию")
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		cfg := Default()
+		cfg.JWT.Secret = strings.Repeat("k", MinSecretLength)
+		return cfg
+	}
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string // "" — конфигурация корректна
+	}{
+		{"по умолчанию с секретом", func(*Config) {}, ""},
+		{"нет секрета JWT", func(c *Config) { c.JWT.Secret = "" }, "jwt.secret"},
+		{"короткий секрет JWT", func(c *Config) { c.JWT.Secret = "short" }, "jwt.secret"},
+		{"каталог ключей вместо секрета", func(c *Config) { c.JWT.Secret = ""; c.JWT.KeysDir = "/keys" }, ""},
+		{"refresh короче access", func(c *Config) { c.JWT.RefreshTokenTTL = time.Minute }, "jwt.refresh_token_ttl"},
+		{"длина пароля больше максимальной", func(c *Config) { c.Password.MinLength = MaxPasswordLength + 1 }, "password.min_length"},
+		{"классов символов больше четырех", func(c *Config) { c.Password.MinClasses = 5 }, "password.min_classes"},
+		{"доля трасс больше единицы", func(c *Config) { c.Tracing.SampleRatio = 1.5 }, "tracing.sample_ratio"},
+		{"доля трасс ноль", func(c *Config) { c.Tracing.SampleRatio = 0 }, ""},
+		{"учетные данные по умолчанию в production", func(c *Config) {
+			c.Server.Environment = EnvProduction
+			c.Bootstrap.AllowDefaultCredentials = true
+		}, "bootstrap.allow_default_credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ошибка %v, ожидалось упоминание %q", err, tt.want)
+			}
+		})
+	}
+}
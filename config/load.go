@@ -0,0 +1,262 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfigFile переменная с путем к файлу конфигурации, если не передан -config.
+const EnvConfigFile = "CONFIG_FILE"
+
+const redacted = "******"
+
+// Load собирает конфигурацию из значений по умолчанию, файла, окружения и флагов args
+// (без имени программы) и проверяет ее.
+func Load(args []string) (*Config, error) {
+	cfg, err := Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Parse собирает конфигурацию так же, как Load, но без проверки: утилитам,
+// которым нужна только база данных, не нужен, например, секрет JWT.
+func Parse(args []string) (*Config, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv(EnvConfigFile), "файл конфигурации YAML или TOML")
+	flags := make(map[string]*fieldFlag, len(fields))
+	for _, f := range fields {
+		ff := &fieldFlag{field: f}
+		flags[f.flag] = ff
+		fs.Var(ff, f.flag, "переменная "+f.env)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("лишние аргументы: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if *path != "" {
+		if err := cfg.loadFile(*path); err != nil {
+			return nil, err
+		}
+	}
+	if err := loadEnv(fields); err != nil {
+		return nil, err
+	}
+	var errs []error
+	fs.Visit(func(fl *flag.Flag) {
+		if ff, ok := flags[fl.Name]; ok {
+			if err := ff.field.set(ff.raw); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", fl.Name, err))
+			}
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile читает файл; формат определяется по расширению. Неизвестные ключи — ошибка,
+// чтобы опечатка в имени параметра не проходила молча.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%s: неизвестный параметр %s", path, undecoded[0])
+		}
+	default:
+		return fmt.Errorf("%s: поддерживаются файлы .yaml, .yml и .toml", path)
+	}
+	return nil
+}
+
+// loadEnv применяет переменные окружения. NAME_FILE читает значение из файла;
+// задавать одновременно NAME и NAME_FILE нельзя.
+func loadEnv(fields []*field) error {
+	var errs []error
+	for _, f := range fields {
+		value, ok := os.LookupEnv(f.env)
+		if file, fromFile := os.LookupEnv(f.env + "_FILE"); fromFile {
+			if ok {
+				errs = append(errs, fmt.Errorf("заданы одновременно %s и %s_FILE", f.env, f.env))
+				continue
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s_FILE: %w", f.env, err))
+				continue
+			}
+			value, ok = strings.TrimRight(string(data), "\r\n"), true
+		}
+		if !ok {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Redacted копия конфигурации, в которой секреты заменены звездочками,
+// а в адресе базы данных скрыт только пароль.
+func (c *Config) Redacted() *Config {
+	cp := *c
+	cp.Auth.MFARequiredRoles = append([]string(nil), c.Auth.MFARequiredRoles...)
+	cp.Server.TrustedProxies = append([]string(nil), c.Server.TrustedProxies...)
+	for _, f := range cp.fields() {
+		if f.secret == "" || f.value.String() == "" {
+			continue
+		}
+		value := redacted
+		if u, err := url.Parse(f.value.String()); f.secret == "url" && err == nil && u.User != nil {
+			value = u.Redacted()
+		}
+		f.value.SetString(value)
+	}
+	return &cp
+}
+
+// WriteYAML выводит конфигурацию в формате файла конфигурации.
+func (c *Config) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// field параметр конфигурации: значение в структуре и его имена в окружении и флагах.
+type field struct {
+	value  reflect.Value
+	env    string
+	flag   string // секция.ключ с дефисами: server.http-addr
+	secret string // "", "true" или "url"
+}
+
+// fields параметры двух уровней: секция Config и поле секции с тегом env.
+func (c *Config) fields() []*field {
+	var fields []*field
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Field(i)
+		sectionName := root.Type().Field(i).Tag.Get("yaml")
+		for j := 0; j < section.NumField(); j++ {
+			sf := section.Type().Field(j)
+			env := sf.Tag.Get("env")
+			if env == "" {
+				continue
+			}
+			fields = append(fields, &field{
+				value:  section.Field(j),
+				env:    env,
+				flag:   sectionName + "." + strings.ReplaceAll(sf.Tag.Get("yaml"), "_", "-"),
+				secret: sf.Tag.Get("secret"),
+			})
+		}
+	}
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set разбирает строковое значение из окружения или флага по типу поля.
+func (f *field) set(s string) error {
+	v := f.value
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		// список через запятую: MFA_REQUIRED_ROLES=admin,auditor
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("неподдерживаемый тип %s", v.Type())
+	}
+	return nil
+}
+
+// fieldFlag флаг командной строки; значение применяется после окружения.
+type fieldFlag struct {
+	field *field
+	raw   string
+}
+
+func (f *fieldFlag) String() string { return f.raw }
+
+func (f *fieldFlag) Set(s string) error {
+	f.raw = s
+	return nil
+}
+
+// IsBoolFlag разрешает писать -auth.registration-enabled без значения.
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.field != nil && f.field.value.Kind() == reflect.Bool
+}
@@ -0,0 +1,76 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"work/services"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain домен в ErrorInfo; Reason — тот же стабильный код, что и в HTTP API.
+const errorDomain = "users"
+
+var errDeleteSelf = services.NewError(services.ErrValidation, "delete_self", "нельзя удалить самого себя")
+
+// toStatus переводит ошибку сервиса в статус gRPC с деталями ErrorInfo
+// (и BadRequest для ошибок полей, RetryInfo для блокировки входа).
+func toStatus(ctx context.Context, err error) error {
+	var domainErr *services.Error
+	if !errors.As(err, &domainErr) || kindCode(domainErr.Kind) == codes.Internal {
+		slog.ErrorContext(ctx, "ошибка обработки вызова gRPC", "error", err)
+		return status.Error(codes.Internal, "внутренняя ошибка сервера")
+	}
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   domainErr.Code,
+		Domain:   errorDomain,
+		Metadata: domainErr.Params,
+	}}
+	if len(domainErr.Fields) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, f := range domainErr.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+				Reason:      f.Code,
+			})
+		}
+		details = append(details, br)
+	}
+	var lockErr *services.LockoutError
+	if errors.As(err, &lockErr) {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(lockErr.RetryAfter)})
+	}
+	return withDetails(status.New(kindCode(domainErr.Kind), domainErr.Message), details...)
+}
+
+// kindCode код gRPC для категории доменной ошибки.
+func kindCode(kind error) codes.Code {
+	switch kind {
+	case services.ErrNotFound:
+		return codes.NotFound
+	case services.ErrConflict:
+		return codes.AlreadyExists
+	case services.ErrValidation:
+		return codes.InvalidArgument
+	case services.ErrUnauthorized:
+		return codes.Unauthenticated
+	case services.ErrForbidden:
+		return codes.PermissionDenied
+	case services.ErrLocked, services.ErrRateLimited:
+		return codes.ResourceExhausted
+	}
+	return codes.Internal
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
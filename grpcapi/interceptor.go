@@ -0,0 +1,128 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+	"work/grpcapi/usersv1"
+	"work/logging"
+	"work/models"
+	"work/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TokenVerifier проверка access токена и прав; реализуется services.UserServiceDb
+// и используется так же, как в HTTP AuthMiddleware и RequirePermission.
+type TokenVerifier interface {
+	VerifyAccessToken(ctx context.Context, token string) (*models.JwtUser, error)
+	Authorize(claims *models.JwtUser, permission string) error
+}
+
+// publicMethods вызываются без токена.
+var publicMethods = map[string]bool{
+	usersv1.UsersService_Login_FullMethodName:        true,
+	usersv1.UsersService_LoginMFA_FullMethodName:     true,
+	usersv1.UsersService_RefreshToken_FullMethodName: true,
+}
+
+// selfMethods работают только с собственной учетной записью и, как /api/v1/me и
+// /api/v1/logout в HTTP, доступны с действующим токеном без проверки Authorize:
+// в том числе до смены временного пароля и до входа со вторым фактором.
+var selfMethods = map[string]bool{
+	usersv1.UsersService_GetMe_FullMethodName:  true,
+	usersv1.UsersService_Logout_FullMethodName: true,
+}
+
+// methodPermissions права, которые требуют остальные методы. Метод, которого нет
+// ни здесь, ни в publicMethods и selfMethods, отклоняется.
+var methodPermissions = map[string]string{
+	usersv1.UsersService_GetUser_FullMethodName:    "users:read",
+	usersv1.UsersService_ListUsers_FullMethodName:  "users:read",
+	usersv1.UsersService_CreateUser_FullMethodName: "users:create",
+	usersv1.UsersService_UpdateUser_FullMethodName: "users:update",
+	usersv1.UsersService_DeleteUser_FullMethodName: "users:delete",
+}
+
+type claimsKey struct{}
+
+// ClaimsFromContext данные токена, проверенного AuthInterceptor.
+func ClaimsFromContext(ctx context.Context) (*models.JwtUser, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*models.JwtUser)
+	return claims, ok
+}
+
+// AuthInterceptor проверяет токен из метаданных "authorization: Bearer <token>"
+// и права на метод, затем кладет claims в контекст.
+func AuthInterceptor(verifier TokenVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		var header string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				header = values[0]
+			}
+		}
+		token, err := services.BearerToken(header)
+		if err != nil {
+			return nil, toStatus(ctx, err)
+		}
+		claims, err := verifier.VerifyAccessToken(ctx, token)
+		if err != nil {
+			return nil, toStatus(ctx, err)
+		}
+		if !selfMethods[info.FullMethod] {
+			permission, ok := methodPermissions[info.FullMethod]
+			if !ok {
+				return nil, status.Error(codes.PermissionDenied, "метод не разрешен")
+			}
+			if err = verifier.Authorize(claims, permission); err != nil {
+				return nil, toStatus(ctx, err)
+			}
+		}
+		ctx = logging.WithUserID(context.WithValue(ctx, claimsKey{}, claims), claims.UserID)
+		return handler(ctx, req)
+	}
+}
+
+// requestIDMetadata ключ метаданных с идентификатором запроса, как X-Request-ID в HTTP.
+const requestIDMetadata = "x-request-id"
+
+// LoggingInterceptor берет идентификатор запроса из метаданных или генерирует новый,
+// возвращает его в заголовке ответа и пишет в лог каждый вызов.
+func LoggingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadata); len(values) > 0 && len(values[0]) <= 128 {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	ctx = logging.WithRequestID(ctx, id)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+
+	resp, err := handler(ctx, req)
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	}
+	slog.LogAttrs(ctx, level, "grpc request",
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+	)
+	return resp, err
+}
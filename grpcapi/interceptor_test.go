@@ -0,0 +1,91 @@
+package grpcapi
+
+import (
+	"context"
+	"testing"
+	"work/grpcapi/usersv1"
+	"work/models"
+	"work/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeVerifier выдает claims по строке токена; Authorize — настоящий из services.
+type fakeVerifier struct {
+	*services.UserServiceDb
+	tokens map[string]*models.JwtUser
+}
+
+func (v fakeVerifier) VerifyAccessToken(_ context.Context, token string) (*models.JwtUser, error) {
+	claims, ok := v.tokens[token]
+	if !ok {
+		return nil, services.ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	verifier := fakeVerifier{
+		// для admin обязателен второй фактор
+		UserServiceDb: services.NewUserService(nil, services.WithMFARequiredRoles(services.RoleAdmin)),
+		tokens: map[string]*models.JwtUser{
+			"full": {UserID: 1, Role: services.RoleAdmin, AMR: []string{"pwd", "otp"},
+				Permissions: []string{"users:read", "users:delete"}},
+			"no-perms": {UserID: 2, Role: services.RoleUser, AMR: []string{"pwd"}},
+			"pwd-change": {UserID: 3, Role: services.RoleAdmin, AMR: []string{"pwd", "otp"}, PwdChange: true,
+				Permissions: []string{"users:read"}},
+			"mfa-pending": {UserID: 4, Role: services.RoleAdmin, AMR: []string{"pwd"},
+				Permissions: []string{"users:read"}},
+		},
+	}
+	interceptor := AuthInterceptor(verifier)
+
+	tests := []struct {
+		method string
+		token  string
+		want   codes.Code
+	}{
+		{usersv1.UsersService_Login_FullMethodName, "", codes.OK},
+		{usersv1.UsersService_GetMe_FullMethodName, "", codes.Unauthenticated},
+		{usersv1.UsersService_GetMe_FullMethodName, "invalid", codes.Unauthenticated},
+		{usersv1.UsersService_GetMe_FullMethodName, "full", codes.OK},
+		// как /api/v1/me и /api/v1/logout: без Authorize
+		{usersv1.UsersService_GetMe_FullMethodName, "pwd-change", codes.OK},
+		{usersv1.UsersService_GetMe_FullMethodName, "mfa-pending", codes.OK},
+		{usersv1.UsersService_Logout_FullMethodName, "pwd-change", codes.OK},
+		{usersv1.UsersService_Logout_FullMethodName, "mfa-pending", codes.OK},
+		{usersv1.UsersService_GetUser_FullMethodName, "full", codes.OK},
+		{usersv1.UsersService_ListUsers_FullMethodName, "no-perms", codes.PermissionDenied},
+		{usersv1.UsersService_ListUsers_FullMethodName, "pwd-change", codes.PermissionDenied},
+		{usersv1.UsersService_ListUsers_FullMethodName, "mfa-pending", codes.PermissionDenied},
+		{usersv1.UsersService_DeleteUser_FullMethodName, "full", codes.OK},
+		{usersv1.UsersService_CreateUser_FullMethodName, "full", codes.PermissionDenied},
+		{"/users.v1.UsersService/Unknown", "full", codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+"/"+tt.token, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+tt.token))
+			}
+			called := false
+			handler := func(ctx context.Context, _ any) (any, error) {
+				called = true
+				if _, ok := ClaimsFromContext(ctx); !ok && !publicMethods[tt.method] {
+					t.Error("claims не переданы в контекст")
+				}
+				return nil, nil
+			}
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("код %s, ожидался %s (%v)", got, tt.want, err)
+			}
+			if called != (tt.want == codes.OK) {
+				t.Fatalf("обработчик вызван: %v", called)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+// Package grpcapi gRPC-сервер API пользователей поверх тех же сервисов, что и HTTP API.
+//
+// Код в usersv1 генерируется из proto/users/v1/users.proto командой `buf generate`
+// в корне репозитория (плагины protoc-gen-go и protoc-gen-go-grpc).
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"work/grpcapi/usersv1"
+	"work/models"
+	"work/services"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// UserService методы services.UserServiceDb, которые использует gRPC API.
+type UserService interface {
+	TokenVerifier
+	Authenticate(ctx context.Context, login, password, ip string) (*models.User, error)
+	ValidateLoginRequest(req *models.LoginRequest) error
+	IssueTokens(ctx context.Context, user *models.User, mfa bool) (*models.AuthResponse, error)
+	CompleteMFALogin(ctx context.Context, challenge, code, ip string) (*models.AuthResponse, error)
+	RotateRefreshToken(ctx context.Context, token string) (*models.AuthResponse, error)
+	Logout(ctx context.Context, claims *models.JwtUser, refreshToken string) error
+	GetUser(ctx context.Context, id int) (*models.User, error)
+	ListUsers(ctx context.Context, q models.UserListQuery) (*models.UserPage, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	UpdateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id int) error
+}
+
+type Server struct {
+	usersv1.UnimplementedUsersServiceServer
+
+	grpc *grpc.Server
+	user UserService
+}
+
+func New(service UserService, opts ...grpc.ServerOption) *Server {
+	opts = append(opts,
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(LoggingInterceptor, AuthInterceptor(service)))
+	s := &Server{
+		grpc: grpc.NewServer(opts...),
+		user: service,
+	}
+	usersv1.RegisterUsersServiceServer(s.grpc, s)
+	return s
+}
+
+func (s *Server) Run(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.grpc.Serve(lis)
+}
+
+// Stop дожидается завершения текущих вызовов, а по истечении ctx обрывает их.
+func (s *Server) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		return ctx.Err()
+	}
+}
+
+func (s *Server) Login(ctx context.Context, req *usersv1.LoginRequest) (*usersv1.LoginResponse, error) {
+	login := &models.LoginRequest{Login: req.GetLogin(), Password: req.GetPassword()}
+	if err := s.user.ValidateLoginRequest(login); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	user, err := s.user.Authenticate(ctx, login.Login, login.Password, peerIP(ctx))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	if user.MFAEnabled {
+		mfaToken, err := services.GenerateMFAChallenge(user)
+		if err != nil {
+			return nil, toStatus(ctx, err)
+		}
+		return &usersv1.LoginResponse{Result: &usersv1.LoginResponse_MfaToken{MfaToken: mfaToken}}, nil
+	}
+	resp, err := s.user.IssueTokens(ctx, user, false)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &usersv1.LoginResponse{Result: &usersv1.LoginResponse_Tokens{Tokens: toAuthResponse(resp)}}, nil
+}
+
+func (s *Server) LoginMFA(ctx context.Context, req *usersv1.LoginMFARequest) (*usersv1.AuthResponse, error) {
+	resp, err := s.user.CompleteMFALogin(ctx, req.GetMfaToken(), req.GetCode(), peerIP(ctx))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toAuthResponse(resp), nil
+}
+
+func (s *Server) RefreshToken(ctx context.Context, req *usersv1.RefreshTokenRequest) (*usersv1.AuthResponse, error) {
+	resp, err := s.user.RotateRefreshToken(ctx, req.GetRefreshToken())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toAuthResponse(resp), nil
+}
+
+func (s *Server) Logout(ctx context.Context, req *usersv1.LogoutRequest) (*usersv1.LogoutResponse, error) {
+	claims, _ := ClaimsFromContext(ctx)
+	if err := s.user.Logout(ctx, claims, req.GetRefreshToken()); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &usersv1.LogoutResponse{}, nil
+}
+
+func (s *Server) GetMe(ctx context.Context, _ *usersv1.GetMeRequest) (*usersv1.User, error) {
+	claims, _ := ClaimsFromContext(ctx)
+	user, err := s.user.GetUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toUser(user), nil
+}
+
+func (s *Server) GetUser(ctx context.Context, req *usersv1.GetUserRequest) (*usersv1.User, error) {
+	user, err := s.user.GetUser(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toUser(user), nil
+}
+
+func (s *Server) ListUsers(ctx context.Context, req *usersv1.ListUsersRequest) (*usersv1.ListUsersResponse, error) {
+	page, err := s.user.ListUsers(ctx, models.UserListQuery{
+		Limit:       int(req.GetLimit()),
+		Cursor:      req.GetCursor(),
+		Role:        req.GetRole(),
+		LoginPrefix: req.GetLoginPrefix(),
+		Sort:        req.GetSort(),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	resp := &usersv1.ListUsersResponse{
+		Items:      make([]*usersv1.UserSummary, len(page.Items)),
+		NextCursor: page.NextCursor,
+		Total:      int64(page.Total),
+	}
+	for i, u := range page.Items {
+		resp.Items[i] = &usersv1.UserSummary{Id: int64(u.ID), Login: u.Login, Role: u.Role}
+	}
+	return resp, nil
+}
+
+func (s *Server) CreateUser(ctx context.Context, req *usersv1.CreateUserRequest) (*usersv1.User, error) {
+	user := &models.User{
+		Login:    req.GetLogin(),
+		Password: req.GetPassword(),
+		Role:     req.GetRole(),
+		Email:    req.Email,
+	}
+	if err := s.user.CreateUser(ctx, user); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toUser(user), nil
+}
+
+func (s *Server) UpdateUser(ctx context.Context, req *usersv1.UpdateUserRequest) (*usersv1.User, error) {
+	user := &models.User{
+		ID:       int(req.GetId()),
+		Login:    req.GetLogin(),
+		Password: req.GetPassword(),
+		Role:     req.GetRole(),
+		Email:    req.Email,
+	}
+	if err := s.user.UpdateUser(ctx, user); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toUser(user), nil
+}
+
+func (s *Server) DeleteUser(ctx context.Context, req *usersv1.DeleteUserRequest) (*usersv1.DeleteUserResponse, error) {
+	if claims, ok := ClaimsFromContext(ctx); ok && int64(claims.UserID) == req.GetId() {
+		return nil, toStatus(ctx, errDeleteSelf)
+	}
+	if err := s.user.DeleteUser(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &usersv1.DeleteUserResponse{}, nil
+}
+
+// peerIP адрес клиента для защиты от перебора паролей.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+func toUser(u *models.User) *usersv1.User {
+	return &usersv1.User{
+		Id:            int64(u.ID),
+		Login:         u.Login,
+		Role:          u.Role,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		MfaEnabled:    u.MFAEnabled,
+	}
+}
+
+func toAuthResponse(resp *models.AuthResponse) *usersv1.AuthResponse {
+	return &usersv1.AuthResponse{
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+		User:         toUser(&resp.User),
+	}
+}
@@ -0,0 +1,175 @@
+// Package i18n каталог сообщений API на разных языках.
+//
+// Сообщения хранятся в JSON-файлах вида {"код": "текст"}, имя файла — тег языка
+// (ru.json, en.json, pt-BR.json). Встроенные каталоги лежат в locales/; файлы из
+// каталога, переданного в LoadDir, добавляют новые языки или переопределяют тексты
+// без пересборки. В текстах допускаются подстановки {имя}.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var builtin embed.FS
+
+// Bundle сообщения всех загруженных языков и язык по умолчанию.
+type Bundle struct {
+	mu       sync.RWMutex
+	def      string
+	messages map[string]map[string]string
+	tags     []language.Tag // tags[0] — язык по умолчанию
+	matcher  language.Matcher
+}
+
+// NewBundle создает каталог со встроенными языками. defaultLang используется,
+// если Accept-Language не задан или ни один язык из него не поддерживается.
+func NewBundle(defaultLang string) (*Bundle, error) {
+	b := &Bundle{messages: make(map[string]map[string]string)}
+	if err := b.load(builtin, "locales"); err != nil {
+		return nil, err
+	}
+	if err := b.SetDefault(defaultLang); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Must паникует при ошибке создания каталога. Встроенные файлы проверяются при сборке,
+// поэтому ошибка возможна только при неверном языке по умолчанию.
+func Must(b *Bundle, err error) *Bundle {
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// LoadDir загружает *.json из каталога поверх уже загруженных сообщений.
+func (b *Bundle) LoadDir(dir string) error {
+	return b.load(os.DirFS(dir), ".")
+}
+
+func (b *Bundle) load(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		tag, err := language.Parse(strings.TrimSuffix(path.Base(file), ".json"))
+		if err != nil {
+			return fmt.Errorf("каталог %s: %w", file, err)
+		}
+		var msgs map[string]string
+		if err = json.Unmarshal(data, &msgs); err != nil {
+			return fmt.Errorf("каталог %s: %w", file, err)
+		}
+		lang := tag.String()
+		if b.messages[lang] == nil {
+			b.messages[lang] = make(map[string]string, len(msgs))
+		}
+		for key, msg := range msgs {
+			b.messages[lang][key] = msg
+		}
+	}
+	b.rebuild()
+	return nil
+}
+
+// SetDefault задает язык по умолчанию; для него должен быть загружен каталог.
+func (b *Bundle) SetDefault(lang string) error {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.messages[tag.String()] == nil {
+		return fmt.Errorf("нет каталога сообщений для языка %s", lang)
+	}
+	b.def = tag.String()
+	b.rebuild()
+	return nil
+}
+
+// rebuild пересобирает matcher; язык по умолчанию должен быть первым.
+func (b *Bundle) rebuild() {
+	langs := make([]string, 0, len(b.messages))
+	for lang := range b.messages {
+		if lang != b.def {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	if b.def != "" {
+		langs = append([]string{b.def}, langs...)
+	}
+	b.tags = make([]language.Tag, len(langs))
+	for i, lang := range langs {
+		b.tags[i] = language.Make(lang)
+	}
+	b.matcher = language.NewMatcher(b.tags)
+}
+
+// Languages загруженные языки, первым — язык по умолчанию.
+func (b *Bundle) Languages() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	langs := make([]string, len(b.tags))
+	for i, tag := range b.tags {
+		langs[i] = tag.String()
+	}
+	return langs
+}
+
+// Match выбирает язык ответа по заголовку Accept-Language.
+func (b *Bundle) Match(acceptLanguage string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if acceptLanguage == "" {
+		return b.def
+	}
+	prefs, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(prefs) == 0 {
+		return b.def
+	}
+	_, index, confidence := b.matcher.Match(prefs...)
+	if confidence == language.No {
+		return b.def
+	}
+	return b.tags[index].String()
+}
+
+// Message возвращает текст по ключу на языке lang, а если перевода нет — на языке
+// по умолчанию. ok = false, если ключа нет ни в одном из них.
+func (b *Bundle) Message(lang, key string, params map[string]string) (msg string, ok bool) {
+	b.mu.RLock()
+	msg, ok = b.messages[lang][key]
+	if !ok {
+		msg, ok = b.messages[b.def][key]
+	}
+	b.mu.RUnlock()
+	if !ok || len(params) == 0 {
+		return msg, ok
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg), true
+}
@@ -0,0 +1,15 @@
+package mailers
+
+import (
+	"context"
+	"log/slog"
+	"work/services"
+)
+
+// LogMailer выводит письма в лог вместо отправки.
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, msg services.MailMessage) error {
+	slog.InfoContext(ctx, "письмо", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
@@ -0,0 +1,12 @@
+package models
+
+type HealthResponse struct { //ответ /healthz и /readyz
+	Status string                 `json:"status"` //ok или unavailable
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type CheckResult struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
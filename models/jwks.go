@@ -0,0 +1,16 @@
+package models
+
+type JWKS struct { //набор публичных ключей для проверки токенов (RFC 7517)
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   //модуль RSA
+	E   string `json:"e,omitempty"`   //экспонента RSA
+	Crv string `json:"crv,omitempty"` //кривая OKP
+	X   string `json:"x,omitempty"`   //публичный ключ Ed25519
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type LoginAttempt struct { //счетчик неудачных попыток входа по логину или IP
+	Scope         string     `db:"scope"`
+	Key           string     `db:"key"`
+	Failures      int        `db:"failures"`
+	LastFailureAt time.Time  `db:"last_failure_at"`
+	LockedUntil   *time.Time `db:"locked_until"`
+}
@@ -0,0 +1,18 @@
+package models
+
+type Problem struct { //тело ошибки в формате RFC 7807 (application/problem+json)
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`             //стабильный машинный код ошибки
+	Errors   []FieldError `json:"errors,omitempty"` //ошибки отдельных полей запроса
+}
+
+type FieldError struct {
+	Field   string            `json:"field"`
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Params  map[string]string `json:"params,omitempty"` //например {"min": "8"}
+}
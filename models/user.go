@@ -1,12 +1,27 @@
 package models
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type User struct {
 	ID       int    `json:"id" db:"id"`
 	Login    string `json:"login" db:"login"`
 	Password string `json:"password" db:"password"`
 	Role     string `json:"role" db:"role"`
+	// адрес почты необязателен для учетных записей, созданных администратором
+	Email         *string `json:"email,omitempty" db:"email"`
+	EmailVerified bool    `json:"email_verified" db:"email_verified"`
+	// токены, выпущенные раньше этого момента, считаются отозванными
+	TokensValidAfter time.Time `json:"-" db:"tokens_valid_after"`
+	// двухфакторная аутентификация (TOTP)
+	MFASecret   *string `json:"-" db:"mfa_secret"`
+	MFAEnabled  bool    `json:"mfa_enabled" db:"mfa_enabled"`
+	MFALastStep *int64  `json:"-" db:"mfa_last_step"`
+	// пароль выдан администратором или при первоначальной настройке: до смены доступны только /me
+	MustChangePassword bool `json:"must_change_password" db:"must_change_password"`
 }
 
 type AllUser struct {
@@ -16,9 +31,12 @@ type AllUser struct {
 }
 
 type JwtUser struct { //структура jwt токена
-	UserID int    `json:"user_id"`
-	Login  string `json:"login"`
-	Role   string `json:"role"`
+	UserID      int      `json:"user_id"`
+	Login       string   `json:"login"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions,omitempty"` //права роли на момент выпуска токена
+	AMR         []string `json:"amr,omitempty"`         //способы аутентификации: pwd, otp
+	PwdChange   bool     `json:"pwd_change,omitempty"`  //до смены пароля права токена не действуют
 	jwt.RegisteredClaims
 }
 type LoginRequest struct { //структура авторизации
@@ -27,6 +45,89 @@ type LoginRequest struct { //структура авторизации
 }
 
 type AuthResponse struct { //структура для вывода информации после авторизации
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` //время жизни access токена в секундах
+	User         User   `json:"user"`
+}
+
+type RefreshRequest struct { //структура запроса на обновление токена
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutRequest struct { //refresh токен, который нужно отозвать вместе с access токеном
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshToken struct { //запись refresh токена в базе, хранится только хэш
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	FamilyID  string     `db:"family_id"`
+	TokenHash string     `db:"token_hash"`
+	MFA       bool       `db:"mfa"` //семейство начато со вторым фактором
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+type MFAChallengeResponse struct { //ответ на вход по паролю, если включен второй фактор
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type MFALoginRequest struct { //второй шаг входа: код TOTP или код восстановления
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type MFAEnrollResponse struct { //данные для добавления в приложение-аутентификатор
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesResponse struct { //коды восстановления показываются один раз
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type UpdateProfileRequest struct { //изменение своего профиля; роль менять нельзя
+	Login *string `json:"login"`
+	Role  *string `json:"role"`
+}
+
+type ChangePasswordRequest struct { //смена своего пароля
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type RegisterRequest struct { //самостоятельная регистрация
+	Login    string `json:"login"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type VerifyEmailRequest struct {
 	Token string `json:"token"`
-	User  User   `json:"user"`
+}
+
+type EmailRequest struct { //повторная отправка письма
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct { //установка нового пароля по токену из письма
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type UserToken struct { //одноразовый токен (подтверждение почты, сброс пароля), хранится только хэш
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
 }
@@ -0,0 +1,28 @@
+package models
+
+type UserListQuery struct { //параметры запроса списка пользователей
+	Limit       int    `query:"limit"`
+	Cursor      string `query:"cursor"`       //курсор из next_cursor предыдущей страницы
+	Role        string `query:"role"`         //фильтр по роли
+	LoginPrefix string `query:"login_prefix"` //фильтр по началу логина
+	Sort        string `query:"sort"`         //поле сортировки: login, id, role; "-" в начале — по убыванию
+}
+
+type UserPage struct { //страница списка пользователей
+	Items      []AllUser `json:"items"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Total      int       `json:"total"`
+}
+
+// UserListFilter параметры выборки для хранилища. Если HasAfter, возвращаются
+// записи строго после (AfterValue, AfterID) в порядке сортировки.
+type UserListFilter struct {
+	Role        string
+	LoginPrefix string
+	SortField   string
+	Desc        bool
+	HasAfter    bool
+	AfterValue  string
+	AfterID     int
+	Limit       int
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"work/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Ошибки проверки access токена; общие для HTTP (AuthMiddleware) и gRPC (interceptor).
+var (
+	ErrTokenMissing   = NewError(ErrUnauthorized, "token_missing", "требуется авторизация")
+	ErrTokenMalformed = NewError(ErrUnauthorized, "token_malformed", "неверный формат токена")
+	ErrTokenInvalid   = NewError(ErrUnauthorized, "token_invalid", "неверный или истекший токен")
+	ErrTokenRevoked   = NewError(ErrUnauthorized, "token_revoked", "токен отозван")
+	ErrMFALoginNeeded = NewError(ErrForbidden, "mfa_required", "требуется вход с двухфакторной аутентификацией")
+	// ErrPasswordChangeNeeded пароль выдан администратором или при первоначальной настройке
+	// и должен быть сменен через POST /api/v1/me/password.
+	ErrPasswordChangeNeeded = NewError(ErrForbidden, "password_change_required", "требуется сменить пароль")
+)
+
+// ErrPermissionDenied в токене нет права, которое требует операция.
+func ErrPermissionDenied(permission string) *Error {
+	err := NewError(ErrForbidden, "permission_denied", "недостаточно прав, требуется право "+permission)
+	err.Params = map[string]string{"permission": permission}
+	return err
+}
+
+// BearerToken извлекает токен из значения заголовка Authorization: "Bearer <token>".
+func BearerToken(header string) (string, error) {
+	if header == "" {
+		observeTokenFailure(ErrTokenMissing)
+		return "", ErrTokenMissing
+	}
+	parts := strings.Split(header, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		observeTokenFailure(ErrTokenMalformed)
+		return "", ErrTokenMalformed
+	}
+	return parts[1], nil
+}
+
+// ParseAccessToken проверяет подпись и срок действия access токена.
+// Токен второго шага входа (audience mfa) доступа не дает.
+func ParseAccessToken(tokenString string) (*models.JwtUser, error) {
+	claims := &models.JwtUser{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, Keys.Keyfunc,
+		jwt.WithValidMethods(Keys.ValidMethods()))
+	if err != nil || !token.Valid || slices.Contains(claims.Audience, MFAChallengeAudience) {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+// VerifyAccessToken проверяет access токен и то, что он не отозван (logout, смена роли, удаление пользователя).
+func (s *UserServiceDb) VerifyAccessToken(ctx context.Context, token string) (claims *models.JwtUser, err error) {
+	ctx, span := startSpan(ctx, "VerifyAccessToken")
+	defer span.End()
+	defer func() {
+		if err != nil {
+			observeTokenFailure(err)
+		}
+	}()
+	claims, err = ParseAccessToken(token)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := s.revocations.IsRevoked(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// Authorize проверяет, что в токене есть право permission (например "users:delete")
+// и соблюдена политика входа со вторым фактором для роли. До смены временного пароля
+// токен не дает никаких прав.
+func (s *UserServiceDb) Authorize(claims *models.JwtUser, permission string) error {
+	if claims.PwdChange {
+		return ErrPasswordChangeNeeded
+	}
+	if !slices.Contains(claims.Permissions, permission) {
+		return ErrPermissionDenied(permission)
+	}
+	if s.MFARequired(claims.Role) && !slices.Contains(claims.AMR, "otp") {
+		return ErrMFALoginNeeded
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"work/models"
+)
+
+var (
+	ErrInvalidPassword = NewError(ErrForbidden, "invalid_password", "неверный пароль")
+	// ErrRoleChangeForbidden пользователь пытается изменить собственную роль.
+	ErrRoleChangeForbidden = NewError(ErrForbidden, "role_change_forbidden", "изменение собственной роли запрещено")
+)
+
+// GetUser возвращает пользователя без хэша пароля.
+func (s *UserServiceDb) GetUser(ctx context.Context, id int) (*models.User, error) {
+	ctx, span := startSpan(ctx, "GetUser")
+	defer span.End()
+	user, err := s.db.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
+// UpdateProfile изменяет профиль пользователем самостоятельно. Роль можно передать
+// только совпадающей с текущей, чтобы исключить повышение своих прав.
+func (s *UserServiceDb) UpdateProfile(ctx context.Context, id int, req *models.UpdateProfileRequest) (*models.User, error) {
+	ctx, span := startSpan(ctx, "UpdateProfile")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	user, err := s.db.GetUserById(txCtx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Role != nil && *req.Role != user.Role {
+		return nil, ErrRoleChangeForbidden
+	}
+	if req.Login != nil && *req.Login != "" {
+		var v validator
+		s.loginPolicy.check(&v, "login", *req.Login)
+		if err = v.err(); err != nil {
+			return nil, err
+		}
+		user.Login = *req.Login
+	}
+	if err = s.db.UpdateUser(txCtx, user); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
+// ChangePassword меняет пароль после проверки текущего и завершает все сессии пользователя.
+// Неверный текущий пароль учитывается защитой от перебора, как при входе.
+func (s *UserServiceDb) ChangePassword(ctx context.Context, id int, currentPassword, newPassword, ip string) error {
+	ctx, span := startSpan(ctx, "ChangePassword")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	user, err := s.db.GetUserById(txCtx, id)
+	if err != nil {
+		return err
+	}
+	if err = s.checkLockout(ctx, user.Login, ip); err != nil {
+		return err
+	}
+	ok, _, err := VerifyPassword(s.hasher, currentPassword, user.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if lockErr := s.registerFailure(ctx, user.Login, ip); lockErr != nil {
+			return lockErr
+		}
+		return ErrInvalidPassword
+	}
+	if err = s.validateNewPassword("new_password", newPassword, user.Login); err != nil {
+		return err
+	}
+	hash, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err = s.db.UpdatePassword(txCtx, id, hash); err != nil {
+		return err
+	}
+	if user.MustChangePassword {
+		if err = s.db.SetMustChangePassword(txCtx, id, false); err != nil {
+			return err
+		}
+	}
+	if err = s.revokeUserSessions(txCtx, id); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	s.revocations.Forget(id)
+	return nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// backgroundTimeout сколько может выполняться фоновая задача, например отправка письма.
+const backgroundTimeout = 30 * time.Second
+
+// goBackground выполняет fn после ответа на запрос. Контекст запроса не отменяется вместе
+// с ним (идентификатор запроса нужен в логе), но ограничен backgroundTimeout.
+// WaitBackground дожидается таких задач при остановке сервера.
+func (s *UserServiceDb) goBackground(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = context.WithoutCancel(ctx)
+	s.background.Go(func() {
+		ctx, cancel := context.WithTimeout(ctx, backgroundTimeout)
+		defer cancel()
+		fn(ctx)
+	})
+}
+
+// WaitBackground дожидается фоновых задач, запущенных обработанными запросами,
+// или истечения ctx. Вызывается при остановке, когда сервер уже не принимает запросы.
+func (s *UserServiceDb) WaitBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"work/models"
+)
+
+// defaultCredentials общеизвестные пары логин/пароль, с которыми приложение
+// раньше поставлялось: admin/admin создавался первой миграцией.
+var defaultCredentials = []struct{ login, password string }{
+	{"admin", "admin"},
+}
+
+// DefaultCredentials логины учетных записей, в которые можно войти с общеизвестным паролем.
+// В production сервер с такими учетными записями не запускается.
+func (s *UserServiceDb) DefaultCredentials(ctx context.Context) ([]string, error) {
+	ctx, span := startSpan(ctx, "DefaultCredentials")
+	defer span.End()
+	var logins []string
+	for _, c := range defaultCredentials {
+		user, err := s.db.GetUserByLogin(ctx, c.login)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ok, _, err := VerifyPassword(s.hasher, c.password, user.Password)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			logins = append(logins, user.Login)
+		}
+	}
+	return logins, nil
+}
+
+// BootstrapAdmin создает первого администратора, если в базе нет ни одного пользователя
+// с ролью admin. Пустой password заменяется сгенерированным, он возвращается в generated
+// и больше нигде не сохраняется. Пароль нужно сменить при первом входе.
+// created = nil, если администратор уже есть.
+func (s *UserServiceDb) BootstrapAdmin(ctx context.Context, login, password string) (created *models.User, generated string, err error) {
+	ctx, span := startSpan(ctx, "BootstrapAdmin")
+	defer span.End()
+	admins, err := s.db.CountUsersWithRole(ctx, RoleAdmin)
+	if err != nil || admins > 0 {
+		return nil, "", err
+	}
+
+	if password == "" {
+		if generated, err = s.GeneratePassword(); err != nil {
+			return nil, "", err
+		}
+		password = generated
+	}
+	user := &models.User{Login: login, Password: password, Role: RoleAdmin}
+	if err = s.ValidateUser(user, true); err != nil {
+		return nil, "", err
+	}
+	if user.Password, err = s.hasher.Hash(password); err != nil {
+		return nil, "", err
+	}
+	user.EmailVerified = true
+	user.MustChangePassword = true
+	if err = s.db.CreateUser(ctx, user); err != nil {
+		// другой экземпляр сервера, запущенный одновременно, успел создать администратора
+		if errors.Is(err, ErrConflict) {
+			if admins, countErr := s.db.CountUsersWithRole(ctx, RoleAdmin); countErr == nil && admins > 0 {
+				slog.InfoContext(ctx, "первый администратор уже создан другим экземпляром")
+				return nil, "", nil
+			}
+		}
+		return nil, "", err
+	}
+	user.Password = ""
+	return user, generated, nil
+}
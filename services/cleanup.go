@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// CleanupInterval как часто удаляются устаревшие служебные записи.
+const CleanupInterval = 10 * time.Minute
+
+// RunCleanup удаляет устаревшие служебные записи сразу и затем раз в interval,
+// пока не отменен ctx. Запускается в отдельной горутине на каждом экземпляре сервера.
+func (s *UserServiceDb) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.cleanup(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *UserServiceDb) cleanup(ctx context.Context) {
+	ctx, span := startSpan(ctx, "Cleanup")
+	defer span.End()
+	// счетчик нужен, пока не истекло самое длинное окно подсчета: входа или писем
+	keep := max(s.lockout.Window, s.mailThrottle.EmailCooldown, s.mailThrottle.IPWindow)
+	deleted, err := s.db.DeleteStaleLoginAttempts(ctx, keep)
+	if err != nil {
+		slog.ErrorContext(ctx, "не удалось удалить устаревшие счетчики попыток входа", "error", err)
+	} else if deleted > 0 {
+		slog.DebugContext(ctx, "удалены устаревшие счетчики попыток входа", "count", deleted)
+	}
+
+	// отзыв нужен, только пока не истек срок самого токена
+	deleted, err = s.db.DeleteExpiredRevokedTokens(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "не удалось удалить истекшие отозванные токены", "error", err)
+	} else if deleted > 0 {
+		slog.DebugContext(ctx, "удалены истекшие отозванные токены", "count", deleted)
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"work/models"
+)
+
+// Категории доменных ошибок. Хранилище и сервисы возвращают ошибки этих категорий
+// (напрямую или через *Error), а api переводит их в HTTP-статусы в одном месте.
+var (
+	ErrNotFound     = errors.New("не найдено")
+	ErrConflict     = errors.New("конфликт данных")
+	ErrValidation   = errors.New("некорректные данные")
+	ErrUnauthorized = errors.New("требуется авторизация")
+	ErrForbidden    = errors.New("доступ запрещен")
+	ErrLocked       = errors.New("заблокировано")
+	ErrRateLimited  = errors.New("слишком много запросов")
+)
+
+// Error доменная ошибка с категорией Kind, стабильным кодом для клиентов
+// и сообщением для человека. errors.Is(err, ErrNotFound) работает для всех ошибок категории.
+type Error struct {
+	Kind    error
+	Code    string // например "user_not_found"; не меняется между версиями
+	Message string
+	Fields  []models.FieldError
+	Params  map[string]string // подстановки для перевода сообщения, например {"permission": "users:delete"}
+}
+
+func NewError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// NewFieldError ошибка валидации одного поля запроса.
+func NewFieldError(field, code, message string) *Error {
+	return &Error{
+		Kind:    ErrValidation,
+		Code:    code,
+		Message: message,
+		Fields:  []models.FieldError{{Field: field, Code: code, Message: message}},
+	}
+}
+
+// NewValidationError ошибка валидации нескольких полей запроса.
+func NewValidationError(fields ...models.FieldError) *Error {
+	return &Error{Kind: ErrValidation, Code: "validation_failed", Message: ErrValidation.Error(), Fields: fields}
+}
+
+func (e *Error) Error() string { return e.Message }
+func (e *Error) Unwrap() error { return e.Kind }
+
+var (
+	ErrUserNotFound = NewError(ErrNotFound, "user_not_found", "пользователь не найден")
+	ErrUserExists   = NewError(ErrConflict, "user_exists", "пользователь с таким логином или адресом почты уже существует")
+	// ErrInvalidCredentials не различает неизвестный логин и неверный пароль.
+	ErrInvalidCredentials = NewError(ErrUnauthorized, "invalid_credentials", "неверный логин или пароль")
+)
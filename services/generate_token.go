@@ -1,32 +1,39 @@
 package services
 
 import (
-	"fmt"
-	"os"
 	"time"
 	"work/models"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// забираем jwt_secret из окружения
-var JwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// Время жизни токенов; задается из конфигурации при запуске.
+var (
+	AccessTokenTTL  = 15 * time.Minute    //время жизни access токена
+	RefreshTokenTTL = 30 * 24 * time.Hour //время жизни refresh токена
+)
 
-func GenerateToken(userID int, login string, role string) (string, error) {
-	if len(JwtSecret) == 0 {
-		return "", fmt.Errorf("JWT_SECRET не установлен")
+// GenerateToken выпускает access токен пользователя с правами роли.
+// amr — способы аутентификации (RFC 8176), например "pwd", "otp".
+func GenerateToken(user *models.User, permissions []string, amr ...string) (string, error) {
+	jti, err := newRandomID() //уникальный идентификатор токена для отзыва
+	if err != nil {
+		return "", err
 	}
 	claims := &models.JwtUser{ //формируем "пакет с данными"
-		UserID: userID,
-		Login:  login,
-		Role:   role,
+		UserID:      user.ID,
+		Login:       user.Login,
+		Role:        user.Role,
+		Permissions: permissions,
+		AMR:         amr,
+		PwdChange:   user.MustChangePassword,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), //срок действия
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)), //срок действия
 			IssuedAt:  jwt.NewNumericDate(time.Now()),                     //когда(а именно сейчас)
-			Subject:   login,                                              //в поле subject помещается Login = кому принадлежит
+			Subject:   user.Login,                                         //в поле subject помещается Login = кому принадлежит
+			ID:        jti,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims) //шифрование токена методом hs256
-	return token.SignedString(JwtSecret)                       //возврат токена или ошибки
+	return Keys.Sign(claims) //подпись активным ключом (HS256, RS256 или EdDSA) с kid в заголовке
 }
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"work/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2id argon2id с малыми параметрами, чтобы тесты шли быстро.
+func testArgon2id() *Argon2idHasher {
+	return &Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func legacySHA256(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestPasswordHashers(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher PasswordHasher
+		// другие параметры того же алгоритма: хэш нужно пересчитать
+		stronger PasswordHasher
+		prefix   string
+	}{
+		{"argon2id", testArgon2id(), &Argon2idHasher{Memory: 2048, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{"bcrypt", NewBcryptHasher(bcrypt.MinCost), NewBcryptHasher(bcrypt.MinCost + 1), "$2a$04$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hasher.Hash("Correct-Horse-42")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Fatalf("хэш %q, ожидался префикс %q", hash, tt.prefix)
+			}
+			if other, _ := tt.hasher.Hash("Correct-Horse-42"); other == hash {
+				t.Error("два хэша одного пароля совпали: соль не используется")
+			}
+			if ok, err := tt.hasher.Verify("Correct-Horse-42", hash); err != nil || !ok {
+				t.Errorf("верный пароль не принят: %v, %v", ok, err)
+			}
+			if ok, err := tt.hasher.Verify("correct-horse-42", hash); err != nil || ok {
+				t.Errorf("неверный пароль принят: %v, %v", ok, err)
+			}
+			if tt.hasher.NeedsRehash(hash) {
+				t.Error("хэш с текущими параметрами требует пересчета")
+			}
+			if !tt.stronger.NeedsRehash(hash) {
+				t.Error("хэш с прежними параметрами не требует пересчета")
+			}
+		})
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	current := testArgon2id()
+	argonHash, err := current.Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := NewBcryptHasher(bcrypt.MinCost).Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		password   string
+		encoded    string
+		wantOK     bool
+		wantRehash bool
+		wantErr    error
+	}{
+		{"argon2id текущий", "Correct-Horse-42", argonHash, true, false, nil},
+		{"argon2id неверный пароль", "wrong", argonHash, false, false, nil},
+		{"bcrypt пересчитывается в argon2id", "Correct-Horse-42", bcryptHash, true, true, nil},
+		{"bcrypt неверный пароль", "wrong", bcryptHash, false, false, nil},
+		{"SHA-256 пересчитывается", "Correct-Horse-42", legacySHA256("Correct-Horse-42"), true, true, nil},
+		{"SHA-256 в верхнем регистре", "Correct-Horse-42", strings.ToUpper(legacySHA256("Correct-Horse-42")), true, true, nil},
+		{"SHA-256 неверный пароль", "wrong", legacySHA256("Correct-Horse-42"), false, false, nil},
+		{"неизвестный формат", "Correct-Horse-42", "Correct-Horse-42", false, false, ErrUnknownHashFormat},
+		{"поврежденный argon2id", "Correct-Horse-42", "$argon2id$v=19$m=1024", false, false, ErrUnknownHashFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := VerifyPassword(current, tt.password, tt.encoded)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ошибка %v, ожидалась %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Fatalf("ok = %v, rehash = %v; ожидалось %v, %v", ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRehashesLegacyPassword(t *testing.T) {
+	hasher := testArgon2id()
+	tests := []struct {
+		name        string
+		password    string
+		wantErr     error
+		wantUpdated bool
+	}{
+		{"верный пароль", "Correct-Horse-42", nil, true},
+		{"неверный пароль", "wrong", ErrInvalidCredentials, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			legacy := legacySHA256("Correct-Horse-42")
+			storage := newMemStorage(&models.User{ID: 1, Login: "alice", Password: legacy, Role: RoleUser})
+			s := NewUserService(storage, WithPasswordHasher(hasher))
+
+			user, err := s.Authenticate(context.Background(), "alice", tt.password, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ошибка %v, ожидалась %v", err, tt.wantErr)
+			}
+			if (storage.passwordSets == 1) != tt.wantUpdated {
+				t.Fatalf("UpdatePassword вызван %d раз", storage.passwordSets)
+			}
+			stored := storage.users[1].Password
+			if !tt.wantUpdated {
+				if stored != legacy {
+					t.Fatalf("хэш изменился после неудачного входа: %q", stored)
+				}
+				return
+			}
+			if !strings.HasPrefix(stored, argon2idPrefix) || user.Password != stored {
+				t.Fatalf("хэш не пересчитан: в хранилище %q, у пользователя %q", stored, user.Password)
+			}
+			// с новым хэшем вход продолжает работать и повторно не пересчитывается
+			if _, err := s.Authenticate(context.Background(), "alice", tt.password, ""); err != nil {
+				t.Fatal(err)
+			}
+			if storage.passwordSets != 1 {
+				t.Fatalf("хэш пересчитан повторно: %d", storage.passwordSets)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"database/sql"
+	"time"
 	"work/models"
 )
 
@@ -15,10 +16,48 @@ type (
 	Storage interface {
 		GetUserByLogin(ctx context.Context, login string) (*models.User, error)
 		GetUserById(ctx context.Context, id int) (*models.User, error)
-		GetAllUsers(ctx context.Context) ([]models.AllUser, error)
+		ListUsers(ctx context.Context, filter models.UserListFilter) ([]models.AllUser, error)
+		CountUsers(ctx context.Context, filter models.UserListFilter) (int, error)
 		CreateUser(ctx context.Context, user *models.User) error
 		UpdateUser(ctx context.Context, user *models.User) error
+		UpdatePassword(ctx context.Context, id int, hash string) error
+		SetMustChangePassword(ctx context.Context, id int, value bool) error
 		DeleteUser(ctx context.Context, id int) error
+		CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+		GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+		MarkRefreshTokenUsed(ctx context.Context, id int) error
+		RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+		RevokeUserRefreshTokens(ctx context.Context, userID int) error
+		RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error
+		IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+		DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+		GetTokensValidAfter(ctx context.Context, userID int) (*time.Time, error)
+		SetTokensValidAfter(ctx context.Context, userID int, t time.Time) error
+		GetLoginAttempt(ctx context.Context, scope, key string) (*models.LoginAttempt, error)
+		RecordLoginFailure(ctx context.Context, scope, key string, window time.Duration) (int, error)
+		SetLoginLockedUntil(ctx context.Context, scope, key string, until time.Time) error
+		ResetLoginAttempts(ctx context.Context, scope, key string) error
+		DeleteStaleLoginAttempts(ctx context.Context, olderThan time.Duration) (int64, error)
+		SetMFASecret(ctx context.Context, userID int, secret *string, enabled bool) error
+		AdvanceMFAStep(ctx context.Context, userID int, step int64) (bool, error)
+		ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error
+		UseRecoveryCode(ctx context.Context, userID int, hash string) (bool, error)
+		GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+		SetEmailVerified(ctx context.Context, userID int) error
+		CreateEmailVerificationToken(ctx context.Context, token *models.UserToken) error
+		GetEmailVerificationToken(ctx context.Context, hash string) (*models.UserToken, error)
+		MarkEmailVerificationTokenUsed(ctx context.Context, id int) error
+		CreatePasswordResetToken(ctx context.Context, token *models.UserToken) error
+		GetPasswordResetToken(ctx context.Context, hash string) (*models.UserToken, error)
+		InvalidatePasswordResetTokens(ctx context.Context, userID int) error
+		ListRoles(ctx context.Context) ([]models.Role, error)
+		GetRole(ctx context.Context, name string) (*models.Role, error)
+		CreateRole(ctx context.Context, role *models.Role) error
+		UpdateRole(ctx context.Context, role *models.Role) error
+		DeleteRole(ctx context.Context, name string) error
+		ListPermissions(ctx context.Context) ([]models.Permission, error)
+		CountUsersWithRole(ctx context.Context, role string) (int, error)
+		RevokeRoleTokens(ctx context.Context, role string, t time.Time) error
 		BeginTx(ctx context.Context, opts *sql.TxOptions) (Transaction, context.Context, error)
 	}
 )
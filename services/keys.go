@@ -0,0 +1,282 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"work/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey = errors.New("ключ подписи не загружен")
+	ErrUnknownKey   = errors.New("неизвестный ключ подписи")
+)
+
+// SigningKey ключ подписи или проверки JWT.
+// Private == nil означает, что ключ используется только для проверки
+// (например, старый ключ во время ротации).
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// KeyManager хранит активный ключ подписи и все ключи, которыми можно проверять токены.
+type KeyManager struct {
+	mu     sync.RWMutex
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// Keys ключи, которыми подписываются и проверяются токены приложения.
+var Keys = NewKeyManager()
+
+func NewKeyManager() *KeyManager {
+	return &KeyManager{keys: make(map[string]*SigningKey)}
+}
+
+// NewHMACKey создает симметричный ключ HS256 (обратная совместимость с JWT_SECRET).
+func NewHMACKey(kid string, secret []byte) *SigningKey {
+	return &SigningKey{ID: kid, Method: jwt.SigningMethodHS256, Private: secret, Public: secret}
+}
+
+// ParseKeyPEM разбирает PEM с приватным (PKCS#1, PKCS#8) или публичным (PKIX) ключом RSA или Ed25519.
+func ParseKeyPEM(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("ключ %s: PEM не найден", kid)
+	}
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("ключ %s: неподдерживаемый тип PEM %q", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ключ %s: %w", kid, err)
+	}
+
+	key := &SigningKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("ключ %s: поддерживаются только RSA и Ed25519", kid)
+	}
+	if rsaKey, ok := key.Public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("ключ %s: длина RSA ключа меньше 2048 бит", kid)
+	}
+	return key, nil
+}
+
+// LoadDir загружает все *.pem из каталога. kid — имя файла без расширения.
+// Активным становится ключ activeKID, а если он не задан — последний по имени
+// приватный ключ (удобно называть файлы по дате: 2026-01.pem, 2026-04.pem).
+func (m *KeyManager) LoadDir(dir, activeKID string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	keys := make(map[string]*SigningKey)
+	var active *SigningKey
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		kid := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		key, err := ParseKeyPEM(kid, data)
+		if err != nil {
+			return err
+		}
+		keys[kid] = key
+		if key.Private != nil && (activeKID == "" || kid == activeKID) {
+			active = key
+		}
+	}
+	if active == nil {
+		return fmt.Errorf("в каталоге %s нет приватного ключа %q", dir, activeKID)
+	}
+
+	m.mu.Lock()
+	m.keys, m.active = keys, active
+	m.mu.Unlock()
+	return nil
+}
+
+// Add добавляет ключ; active = true делает его ключом подписи новых токенов.
+func (m *KeyManager) Add(key *SigningKey, active bool) error {
+	if active && key.Private == nil {
+		return fmt.Errorf("ключ %s: для подписи нужен приватный ключ", key.ID)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key.ID] = key
+	if active {
+		m.active = key
+	}
+	return nil
+}
+
+// Loaded сообщает, есть ли ключ для подписи токенов.
+func (m *KeyManager) Loaded() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active != nil
+}
+
+// ActiveKID kid ключа, которым подписываются новые токены.
+func (m *KeyManager) ActiveKID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.active == nil {
+		return ""
+	}
+	return m.active.ID
+}
+
+// GenerateKeyPEM создает приватный ключ для каталога ключей в формате PKCS#8 PEM:
+// RS256 — RSA 3072 бит, EdDSA — Ed25519.
+func GenerateKeyPEM(alg string) ([]byte, error) {
+	var key crypto.PrivateKey
+	var err error
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		key, err = rsa.GenerateKey(rand.Reader, 3072)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый алгоритм %q: RS256 или EdDSA", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// Sign подписывает claims активным ключом и проставляет kid в заголовок.
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	key := m.active
+	m.mu.RUnlock()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc выбирает ключ проверки по kid и сверяет алгоритм, чтобы исключить подмену (alg confusion).
+func (m *KeyManager) Keyfunc(token *jwt.Token) (any, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var key *SigningKey
+	if kid, ok := token.Header["kid"].(string); ok {
+		key = m.keys[kid]
+	} else if m.active != nil && m.active.Method == jwt.SigningMethodHS256 {
+		// токены, выпущенные до появления kid
+		key = m.active
+	}
+	if key == nil {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("неожиданный метод подписи: %v", token.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+// ValidMethods алгоритмы, которые принимаются при разборе токена.
+func (m *KeyManager) ValidMethods() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range m.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// JWKS возвращает публичные ключи в формате RFC 7517. Симметричные ключи не публикуются.
+func (m *KeyManager) JWKS() models.JWKS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	kids := make([]string, 0, len(m.keys))
+	for kid := range m.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := models.JWKS{Keys: []models.JWK{}}
+	for _, kid := range kids {
+		key := m.keys[kid]
+		jwk := models.JWK{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// LoadSigningKeys настраивает Keys: из каталога PEM-файлов, если он указан,
+// иначе — симметричным ключом secret (HS256).
+func LoadSigningKeys(dir, activeKID string, secret []byte) error {
+	if dir != "" {
+		return Keys.LoadDir(dir, activeKID)
+	}
+	if len(secret) == 0 {
+		return fmt.Errorf("JWT_SECRET не установлен")
+	}
+	return Keys.Add(NewHMACKey("default", secret), true)
+}
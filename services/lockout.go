@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+var (
+	// ErrAccountLocked учетная запись заблокирована после слишком большого числа неудачных входов.
+	ErrAccountLocked = NewError(ErrLocked, "account_locked", "учетная запись временно заблокирована")
+	// ErrTooManyAttempts попытки входа временно ограничены (экспоненциальная задержка или лимит по IP).
+	ErrTooManyAttempts = NewError(ErrRateLimited, "too_many_attempts", "слишком много попыток входа")
+)
+
+const (
+	lockoutScopeLogin = "login"
+	lockoutScopeIP    = "ip"
+)
+
+// LockoutError сообщает, через сколько можно повторить попытку входа.
+type LockoutError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string { return e.Err.Error() }
+func (e *LockoutError) Unwrap() error { return e.Err }
+
+// LockoutPolicy параметры защиты от перебора паролей.
+type LockoutPolicy struct {
+	BackoffAfter    int           // после скольких ошибок включается задержка
+	BaseDelay       time.Duration // задержка после BackoffAfter ошибок, дальше удваивается
+	MaxDelay        time.Duration
+	MaxFailures     int           // ошибок по логину до блокировки учетной записи
+	IPMaxFailures   int           // ошибок с одного IP до блокировки адреса
+	LockoutDuration time.Duration // длительность блокировки
+	Window          time.Duration // через сколько после последней ошибки счетчик обнуляется
+}
+
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		BackoffAfter:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		MaxFailures:     10,
+		IPMaxFailures:   50,
+		LockoutDuration: 15 * time.Minute,
+		Window:          15 * time.Minute,
+	}
+}
+
+// WithLockoutPolicy задает параметры блокировки при переборе паролей.
+func WithLockoutPolicy(p LockoutPolicy) Option {
+	return func(s *UserServiceDb) {
+		s.lockout = p
+	}
+}
+
+// checkLockout запрещает попытку входа, пока действует блокировка логина или IP.
+func (s *UserServiceDb) checkLockout(ctx context.Context, login, ip string) error {
+	now := time.Now()
+	attempt, err := s.db.GetLoginAttempt(ctx, lockoutScopeLogin, login)
+	if err != nil {
+		return err
+	}
+	if attempt != nil && attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+		reason := ErrTooManyAttempts
+		if attempt.Failures >= s.lockout.MaxFailures {
+			reason = ErrAccountLocked
+		}
+		return &LockoutError{Err: reason, RetryAfter: attempt.LockedUntil.Sub(now)}
+	}
+	if ip == "" {
+		return nil
+	}
+	attempt, err = s.db.GetLoginAttempt(ctx, lockoutScopeIP, ip)
+	if err != nil {
+		return err
+	}
+	if attempt != nil && attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+		return &LockoutError{Err: ErrTooManyAttempts, RetryAfter: attempt.LockedUntil.Sub(now)}
+	}
+	return nil
+}
+
+// registerFailure учитывает неудачный вход. Возвращает LockoutError,
+// если именно эта попытка привела к блокировке учетной записи.
+func (s *UserServiceDb) registerFailure(ctx context.Context, login, ip string) error {
+	var lockErr error
+	failures, err := s.db.RecordLoginFailure(ctx, lockoutScopeLogin, login, s.lockout.Window)
+	if err != nil {
+		slog.ErrorContext(ctx, "не удалось учесть неудачный вход", "login", login, "error", err)
+	} else if delay := s.lockout.delay(failures, s.lockout.MaxFailures); delay > 0 {
+		if err = s.db.SetLoginLockedUntil(ctx, lockoutScopeLogin, login, time.Now().Add(delay)); err != nil {
+			slog.ErrorContext(ctx, "не удалось заблокировать вход", "login", login, "error", err)
+		} else if failures >= s.lockout.MaxFailures {
+			lockErr = &LockoutError{Err: ErrAccountLocked, RetryAfter: delay}
+		}
+	}
+
+	if ip == "" {
+		return lockErr
+	}
+	failures, err = s.db.RecordLoginFailure(ctx, lockoutScopeIP, ip, s.lockout.Window)
+	if err != nil {
+		slog.ErrorContext(ctx, "не удалось учесть неудачный вход", "ip", ip, "error", err)
+	} else if failures >= s.lockout.IPMaxFailures {
+		if err = s.db.SetLoginLockedUntil(ctx, lockoutScopeIP, ip, time.Now().Add(s.lockout.LockoutDuration)); err != nil {
+			slog.ErrorContext(ctx, "не удалось заблокировать вход", "ip", ip, "error", err)
+		}
+	}
+	return lockErr
+}
+
+// delay вычисляет, на сколько закрыть вход после failures ошибок подряд.
+func (p LockoutPolicy) delay(failures, maxFailures int) time.Duration {
+	if failures >= maxFailures {
+		return p.LockoutDuration
+	}
+	if failures < p.BackoffAfter {
+		return 0
+	}
+	delay := p.BaseDelay << (failures - p.BackoffAfter)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// resetFailures обнуляет счетчик логина после успешного входа.
+// Счетчик IP не сбрасывается, чтобы нельзя было обойти лимит входом в свою учетную запись.
+func (s *UserServiceDb) resetFailures(ctx context.Context, login string) {
+	if err := s.db.ResetLoginAttempts(ctx, lockoutScopeLogin, login); err != nil {
+		slog.ErrorContext(ctx, "не удалось сбросить счетчик попыток", "login", login, "error", err)
+	}
+}
+
+// UnlockUser снимает блокировку входа с учетной записи.
+func (s *UserServiceDb) UnlockUser(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "UnlockUser")
+	defer span.End()
+	user, err := s.db.GetUserById(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.db.ResetLoginAttempts(ctx, lockoutScopeLogin, user.Login)
+}
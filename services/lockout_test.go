@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+	"work/models"
+)
+
+func TestLockoutDelay(t *testing.T) {
+	p := DefaultLockoutPolicy()
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{9, 64 * time.Second},
+		{10, 15 * time.Minute}, // MaxFailures: блокировка учетной записи
+		{50, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.delay(tt.failures, p.MaxFailures); got != tt.want {
+			t.Errorf("delay(%d) = %s, ожидалось %s", tt.failures, got, tt.want)
+		}
+	}
+
+	// задержка не превышает MaxDelay, в том числе при переполнении сдвига
+	capped := LockoutPolicy{BackoffAfter: 1, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute,
+		MaxFailures: 1000, LockoutDuration: time.Hour}
+	for _, failures := range []int{4, 10, 100, 999} {
+		if got := capped.delay(failures, capped.MaxFailures); got != capped.MaxDelay {
+			t.Errorf("delay(%d) = %s, ожидалось %s", failures, got, capped.MaxDelay)
+		}
+	}
+}
+
+func TestLockoutThreshold(t *testing.T) {
+	policy := LockoutPolicy{
+		BackoffAfter:    2,
+		BaseDelay:       time.Minute,
+		MaxDelay:        10 * time.Minute,
+		MaxFailures:     3,
+		IPMaxFailures:   5,
+		LockoutDuration: time.Hour,
+		Window:          time.Hour,
+	}
+	tests := []struct {
+		name     string
+		failures int
+		// каждая ошибка с другим логином: срабатывает только лимит IP
+		distinctLogins bool
+		wantLast       error // результат registerFailure на последней ошибке
+		wantCheck      error // результат checkLockout после всех ошибок
+		wantRetry      time.Duration
+	}{
+		{"до задержки", 1, false, nil, nil, 0},
+		{"экспоненциальная задержка", 2, false, nil, ErrTooManyAttempts, time.Minute},
+		{"блокировка учетной записи", 3, false, ErrAccountLocked, ErrAccountLocked, time.Hour},
+		{"IP ниже лимита", 4, true, nil, nil, 0},
+		{"блокировка IP", 5, true, nil, ErrTooManyAttempts, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewUserService(newMemStorage(), WithLockoutPolicy(policy))
+			login := "alice"
+			var last error
+			for i := range tt.failures {
+				if tt.distinctLogins {
+					login = "user" + strconv.Itoa(i)
+				}
+				last = s.registerFailure(ctx, login, "192.0.2.1")
+			}
+			if !errors.Is(last, tt.wantLast) || (tt.wantLast == nil) != (last == nil) {
+				t.Fatalf("registerFailure: %v, ожидалось %v", last, tt.wantLast)
+			}
+
+			err := s.checkLockout(ctx, login, "192.0.2.1")
+			if tt.wantCheck == nil {
+				if err != nil {
+					t.Fatalf("checkLockout: %v", err)
+				}
+				return
+			}
+			var lockErr *LockoutError
+			if !errors.As(err, &lockErr) || !errors.Is(err, tt.wantCheck) {
+				t.Fatalf("checkLockout: %v, ожидалось %v", err, tt.wantCheck)
+			}
+			if lockErr.RetryAfter <= 0 || lockErr.RetryAfter > tt.wantRetry {
+				t.Fatalf("RetryAfter = %s, ожидалось до %s", lockErr.RetryAfter, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestAuthenticateLockedAccount(t *testing.T) {
+	ctx := context.Background()
+	hasher := testArgon2id()
+	hash, err := hasher.Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := newMemStorage(&models.User{ID: 1, Login: "alice", Password: hash, Role: RoleUser})
+	policy := DefaultLockoutPolicy()
+	s := NewUserService(storage, WithPasswordHasher(hasher), WithLockoutPolicy(policy))
+
+	for range policy.BackoffAfter - 1 {
+		if _, err := s.Authenticate(ctx, "alice", "wrong", ""); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("ошибка %v, ожидалась %v", err, ErrInvalidCredentials)
+		}
+	}
+	// успешный вход до задержки обнуляет счетчик логина
+	if _, err := s.Authenticate(ctx, "alice", "Correct-Horse-42", ""); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := storage.GetLoginAttempt(ctx, lockoutScopeLogin, "alice"); a != nil {
+		t.Fatalf("счетчик не сброшен: %+v", a)
+	}
+
+	for range policy.BackoffAfter {
+		_, _ = s.Authenticate(ctx, "alice", "wrong", "")
+	}
+	// пока действует задержка, не принимается и верный пароль
+	if _, err := s.Authenticate(ctx, "alice", "Correct-Horse-42", ""); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("ошибка %v, ожидалась %v", err, ErrTooManyAttempts)
+	}
+}
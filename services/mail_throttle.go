@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ErrMailRateLimited письма на адрес или с одного IP запрашиваются слишком часто.
+var ErrMailRateLimited = NewError(ErrRateLimited, "too_many_requests", "слишком много запросов, повторите позже")
+
+// Области счетчиков писем в хранилище попыток входа.
+const (
+	mailScopeEmail = "mail"
+	mailScopeIP    = "mail_ip"
+)
+
+// MailThrottle ограничения на письма, которые может запросить анонимный клиент:
+// подтверждение адреса и сброс пароля. Действуют для любого адреса, существует
+// учетная запись или нет, поэтому ответ 429 ничего о ней не сообщает.
+type MailThrottle struct {
+	EmailCooldown time.Duration // пауза между письмами на один адрес
+	IPMaxRequests int           // запросов писем с одного IP за IPWindow
+	IPWindow      time.Duration
+}
+
+func DefaultMailThrottle() MailThrottle {
+	return MailThrottle{
+		EmailCooldown: time.Minute,
+		IPMaxRequests: 10,
+		IPWindow:      time.Hour,
+	}
+}
+
+// WithMailThrottle задает ограничения на запросы писем.
+func WithMailThrottle(t MailThrottle) Option {
+	return func(s *UserServiceDb) {
+		s.mailThrottle = t
+	}
+}
+
+// throttleMail учитывает запрос письма на адрес email с адреса ip. Пока действует пауза
+// для адреса или исчерпан лимит IP, возвращает LockoutError со временем до повтора.
+func (s *UserServiceDb) throttleMail(ctx context.Context, email, ip string) error {
+	var v validator
+	checkEmail(&v, "email", email)
+	if err := v.err(); err != nil {
+		return err
+	}
+	email = strings.ToLower(email)
+	now := time.Now()
+	for _, scope := range []struct{ name, key string }{{mailScopeEmail, email}, {mailScopeIP, ip}} {
+		if scope.key == "" {
+			continue
+		}
+		attempt, err := s.db.GetLoginAttempt(ctx, scope.name, scope.key)
+		if err != nil {
+			return err
+		}
+		if attempt != nil && attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			return &LockoutError{Err: ErrMailRateLimited, RetryAfter: attempt.LockedUntil.Sub(now)}
+		}
+	}
+
+	// адрес закрывается на EmailCooldown после каждого запроса
+	if _, err := s.db.RecordLoginFailure(ctx, mailScopeEmail, email, s.mailThrottle.EmailCooldown); err != nil {
+		return err
+	}
+	if err := s.db.SetLoginLockedUntil(ctx, mailScopeEmail, email, now.Add(s.mailThrottle.EmailCooldown)); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	requests, err := s.db.RecordLoginFailure(ctx, mailScopeIP, ip, s.mailThrottle.IPWindow)
+	if err != nil {
+		return err
+	}
+	if requests >= s.mailThrottle.IPMaxRequests {
+		return s.db.SetLoginLockedUntil(ctx, mailScopeIP, ip, now.Add(s.mailThrottle.IPWindow))
+	}
+	return nil
+}
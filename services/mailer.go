@@ -0,0 +1,22 @@
+package services
+
+import "context"
+
+// MailMessage письмо, отправляемое пользователю.
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма. Реализации находятся в пакете mailers (SMTP, файлы, лог).
+type Mailer interface {
+	Send(ctx context.Context, msg MailMessage) error
+}
+
+// WithMailer задает способ отправки писем (подтверждение почты, сброс пароля).
+func WithMailer(m Mailer) Option {
+	return func(s *UserServiceDb) {
+		s.mailer = m
+	}
+}
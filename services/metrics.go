@@ -0,0 +1,53 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Метрики входа и проверки токенов. Метки — только конечные наборы значений
+// (способ входа, код ошибки), без логинов и идентификаторов пользователей.
+var (
+	loginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_attempts_total",
+		Help: "Попытки входа по способу (password, mfa), результату и причине отказа.",
+	}, []string{"method", "result", "reason"})
+
+	tokenValidationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_validation_failures_total",
+		Help: "Отклоненные access токены по причине.",
+	}, []string{"reason"})
+)
+
+// RegisterMetrics регистрирует метрики сервиса.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{loginAttempts, tokenValidationFailures} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observeLogin учитывает результат шага входа.
+func observeLogin(method string, err error) {
+	if err == nil {
+		loginAttempts.WithLabelValues(method, "success", "").Inc()
+		return
+	}
+	loginAttempts.WithLabelValues(method, "failure", errorReason(err)).Inc()
+}
+
+func observeTokenFailure(err error) {
+	tokenValidationFailures.WithLabelValues(errorReason(err)).Inc()
+}
+
+// errorReason стабильный код ошибки для метки; ошибки без кода считаются внутренними.
+func errorReason(err error) string {
+	var domainErr *Error
+	if errors.As(err, &domainErr) && domainErr.Code != "" {
+		return domainErr.Code
+	}
+	return "internal_error"
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"slices"
+	"strings"
+	"time"
+	"work/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// MFAChallengeAudience audience промежуточного токена между паролем и вторым фактором.
+	// AuthMiddleware такие токены не принимает.
+	MFAChallengeAudience = "mfa"
+	MFAChallengeTTL      = 5 * time.Minute
+	recoveryCodesCount   = 10
+)
+
+var (
+	ErrInvalidMFACode      = NewError(ErrValidation, "invalid_mfa_code", "неверный код подтверждения")
+	ErrInvalidMFAChallenge = NewError(ErrUnauthorized, "invalid_mfa_challenge", "недействительный токен второго шага входа")
+	ErrMFANotEnrolled      = NewError(ErrConflict, "mfa_not_enrolled", "двухфакторная аутентификация не настроена")
+	ErrMFAAlreadyEnabled   = NewError(ErrConflict, "mfa_already_enabled", "двухфакторная аутентификация уже включена")
+	// ErrMFALoginFailed неверный код на втором шаге входа.
+	ErrMFALoginFailed = NewError(ErrUnauthorized, "invalid_mfa_code", "неверный код подтверждения")
+)
+
+// WithMFARequiredRoles задает роли, которым доступ к защищенным операциям разрешен только после входа со вторым фактором.
+func WithMFARequiredRoles(roles ...string) Option {
+	return func(s *UserServiceDb) {
+		s.mfaRoles = roles
+	}
+}
+
+// MFARequired сообщает, требует ли политика второй фактор для роли.
+func (s *UserServiceDb) MFARequired(role string) bool {
+	return slices.Contains(s.mfaRoles, role)
+}
+
+// authMethods значения claim amr для выпускаемых токенов.
+func authMethods(mfa bool) []string {
+	if mfa {
+		return []string{"pwd", "otp"}
+	}
+	return []string{"pwd"}
+}
+
+// GenerateMFAChallenge выпускает короткоживущий токен, который обменивается на JWT после ввода кода.
+func GenerateMFAChallenge(user *models.User) (string, error) {
+	claims := &models.JwtUser{
+		UserID: user.ID,
+		Login:  user.Login,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{MFAChallengeAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFAChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   user.Login,
+		},
+	}
+	return Keys.Sign(claims)
+}
+
+func parseMFAChallenge(token string) (*models.JwtUser, error) {
+	claims := &models.JwtUser{}
+	_, err := jwt.ParseWithClaims(token, claims, Keys.Keyfunc,
+		jwt.WithValidMethods(Keys.ValidMethods()),
+		jwt.WithAudience(MFAChallengeAudience),
+		jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidMFAChallenge
+	}
+	return claims, nil
+}
+
+// EnrollMFA генерирует новый секрет TOTP. До подтверждения кодом второй фактор не включается.
+func (s *UserServiceDb) EnrollMFA(ctx context.Context, userID int) (*models.MFAEnrollResponse, error) {
+	ctx, span := startSpan(ctx, "EnrollMFA")
+	defer span.End()
+	user, err := s.db.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.db.SetMFASecret(ctx, userID, &secret, false); err != nil {
+		return nil, err
+	}
+	return &models.MFAEnrollResponse{
+		Secret:     secret,
+		OtpauthURI: TOTPURI(user.Login, secret),
+	}, nil
+}
+
+// ConfirmMFA включает второй фактор после проверки первого кода и выдает коды восстановления.
+func (s *UserServiceDb) ConfirmMFA(ctx context.Context, userID int, code string) ([]string, error) {
+	ctx, span := startSpan(ctx, "ConfirmMFA")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	user, err := s.db.GetUserById(txCtx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	step, ok := ValidateTOTP(*user.MFASecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	if _, err = s.db.AdvanceMFAStep(txCtx, userID, step); err != nil {
+		return nil, err
+	}
+	if err = s.db.SetMFASecret(txCtx, userID, user.MFASecret, true); err != nil {
+		return nil, err
+	}
+	codes, err := s.replaceRecoveryCodes(txCtx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableMFA отключает второй фактор; требуется действующий код TOTP или код восстановления.
+func (s *UserServiceDb) DisableMFA(ctx context.Context, userID int, code string) error {
+	ctx, span := startSpan(ctx, "DisableMFA")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	user, err := s.db.GetUserById(txCtx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnrolled
+	}
+	ok, err := s.verifySecondFactor(txCtx, user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	if err = s.db.SetMFASecret(txCtx, userID, nil, false); err != nil {
+		return err
+	}
+	if err = s.db.ReplaceRecoveryCodes(txCtx, userID, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RegenerateRecoveryCodes заменяет все коды восстановления новыми.
+func (s *UserServiceDb) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	ctx, span := startSpan(ctx, "RegenerateRecoveryCodes")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	user, err := s.db.GetUserById(txCtx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnrolled
+	}
+	ok, err := s.verifySecondFactor(txCtx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	codes, err := s.replaceRecoveryCodes(txCtx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// CompleteMFALogin второй шаг входа: проверяет токен первого шага и код, выдает JWT.
+// Неверные коды учитываются так же, как неверные пароли.
+func (s *UserServiceDb) CompleteMFALogin(ctx context.Context, challenge, code, ip string) (resp *models.AuthResponse, err error) {
+	ctx, span := startSpan(ctx, "CompleteMFALogin")
+	defer span.End()
+	defer func() { observeLogin("mfa", err) }()
+	claims, err := parseMFAChallenge(challenge)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkLockout(ctx, claims.Login, ip); err != nil {
+		return nil, err
+	}
+	user, err := s.db.GetUserById(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrInvalidMFAChallenge
+	}
+	ok, err := s.verifySecondFactor(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if lockErr := s.registerFailure(ctx, user.Login, ip); lockErr != nil {
+			return nil, lockErr
+		}
+		return nil, ErrMFALoginFailed
+	}
+	s.resetFailures(ctx, user.Login)
+	return s.IssueTokens(ctx, user, true)
+}
+
+// verifySecondFactor принимает код TOTP (каждый не более одного раза) или неиспользованный код восстановления.
+func (s *UserServiceDb) verifySecondFactor(ctx context.Context, user *models.User, code string) (bool, error) {
+	if user.MFASecret == nil {
+		return false, nil
+	}
+	if step, ok := ValidateTOTP(*user.MFASecret, code, time.Now()); ok {
+		// код уже был использован, повтор запрещен
+		return s.db.AdvanceMFAStep(ctx, user.ID, step)
+	}
+	return s.db.UseRecoveryCode(ctx, user.ID, hashOpaqueToken(normalizeRecoveryCode(code)))
+}
+
+func (s *UserServiceDb) replaceRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashOpaqueToken(normalizeRecoveryCode(code))
+	}
+	if err := s.db.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCode генерирует код вида XXXXX-XXXXX (50 бит энтропии).
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	s := totpEncoding.EncodeToString(b)[:10]
+	return s[:5] + "-" + s[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"work/models"
+)
+
+var ErrInvalidResetToken = NewError(ErrValidation, "invalid_reset_token", "недействительная ссылка для сброса пароля")
+
+// PasswordResetConfig настройки восстановления пароля.
+type PasswordResetConfig struct {
+	// URL адрес страницы ввода нового пароля, к нему добавляется ?token=...
+	URL      string
+	TokenTTL time.Duration
+}
+
+// WithPasswordReset настраивает письма для сброса пароля.
+func WithPasswordReset(cfg PasswordResetConfig) Option {
+	return func(s *UserServiceDb) {
+		if cfg.TokenTTL == 0 {
+			cfg.TokenTTL = time.Hour
+		}
+		s.reset = cfg
+	}
+}
+
+// RequestPasswordReset отправляет письмо со ссылкой для сброса пароля. Поиск учетной
+// записи, создание токена и отправка письма выполняются в фоне, поэтому ни ответ,
+// ни время ответа не выдают, существует ли учетная запись. Запросы писем на один
+// адрес и с одного IP ограничены (MailThrottle).
+func (s *UserServiceDb) RequestPasswordReset(ctx context.Context, email, ip string) error {
+	ctx, span := startSpan(ctx, "RequestPasswordReset")
+	defer span.End()
+	if err := s.throttleMail(ctx, email, ip); err != nil {
+		return err
+	}
+	s.goBackground(ctx, func(ctx context.Context) {
+		if err := s.sendPasswordReset(ctx, email); err != nil {
+			slog.ErrorContext(ctx, "не удалось отправить письмо сброса пароля", "email", email, "error", err)
+		}
+	})
+	return nil
+}
+
+// sendPasswordReset создает токен сброса и отправляет письмо, если адрес принадлежит пользователю.
+func (s *UserServiceDb) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := s.db.GetUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	err = s.db.CreatePasswordResetToken(ctx, &models.UserToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.reset.TokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, MailMessage{
+		To:      email,
+		Subject: "Восстановление пароля",
+		Body: fmt.Sprintf("Для установки нового пароля перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s. Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.\n",
+			tokenLink(s.reset.URL, token), s.reset.TokenTTL),
+	})
+}
+
+// ResetPassword устанавливает новый пароль по одноразовому токену и завершает все сессии пользователя.
+func (s *UserServiceDb) ResetPassword(ctx context.Context, token, newPassword string) error {
+	ctx, span := startSpan(ctx, "ResetPassword")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t, err := s.db.GetPasswordResetToken(txCtx, hashOpaqueToken(token))
+	if err != nil {
+		return err
+	}
+	if t == nil || t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+	user, err := s.db.GetUserById(txCtx, t.UserID)
+	if err != nil {
+		return err
+	}
+	if err = s.validateNewPassword("new_password", newPassword, user.Login); err != nil {
+		return err
+	}
+	hash, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err = s.db.UpdatePassword(txCtx, t.UserID, hash); err != nil {
+		return err
+	}
+	if user.MustChangePassword {
+		if err = s.db.SetMustChangePassword(txCtx, t.UserID, false); err != nil {
+			return err
+		}
+	}
+	// вместе с использованным гасим и остальные выданные ссылки
+	if err = s.db.InvalidatePasswordResetTokens(txCtx, t.UserID); err != nil {
+		return err
+	}
+	if err = s.revokeUserSessions(txCtx, t.UserID); err != nil {
+		return err
+	}
+	// владелец почты подтвердил себя — снимаем блокировку входа
+	if err = s.db.ResetLoginAttempts(txCtx, lockoutScopeLogin, user.Login); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	s.revocations.Forget(t.UserID)
+	return nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+	"work/models"
+)
+
+var (
+	ErrInvalidRefreshToken = NewError(ErrUnauthorized, "invalid_refresh_token", "недействительный refresh токен")
+	// ErrRefreshTokenReused означает повторное предъявление уже использованного токена.
+	// Все токены семейства при этом отзываются.
+	ErrRefreshTokenReused = NewError(ErrUnauthorized, "refresh_token_reused", "refresh токен использован повторно")
+)
+
+// newOpaqueToken генерирует случайный токен для клиента и его хэш для хранения в базе.
+func newOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashOpaqueToken(token), nil
+}
+
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// IssueTokens выдает пару access и refresh токенов нового семейства после успешного входа.
+// mfa = true, если пользователь подтвердил вход вторым фактором.
+func (s *UserServiceDb) IssueTokens(ctx context.Context, user *models.User, mfa bool) (*models.AuthResponse, error) {
+	ctx, span := startSpan(ctx, "IssueTokens")
+	defer span.End()
+	familyID, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := s.issueRefreshToken(ctx, user.ID, familyID, mfa)
+	if err != nil {
+		return nil, err
+	}
+	return s.newAuthResponse(ctx, user, refreshToken, mfa)
+}
+
+func (s *UserServiceDb) newAuthResponse(ctx context.Context, user *models.User, refreshToken string, mfa bool) (*models.AuthResponse, error) {
+	perms, err := s.rolePermissions(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	token, err := GenerateToken(user, perms, authMethods(mfa)...)
+	if err != nil {
+		return nil, err
+	}
+	// Очищаем пароль перед отправкой
+	user.Password = ""
+	return &models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(AccessTokenTTL.Seconds()),
+		User:         *user,
+	}, nil
+}
+
+func (s *UserServiceDb) issueRefreshToken(ctx context.Context, userID int, familyID string, mfa bool) (string, error) {
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.db.CreateRefreshToken(ctx, &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		MFA:       mfa,
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RotateRefreshToken обменивает refresh токен на новую пару токенов из того же семейства.
+// При повторном использовании токена отзывается всё семейство.
+func (s *UserServiceDb) RotateRefreshToken(ctx context.Context, token string) (*models.AuthResponse, error) {
+	ctx, span := startSpan(ctx, "RotateRefreshToken")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rt, err := s.db.GetRefreshTokenByHash(txCtx, hashOpaqueToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if rt == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if rt.UsedAt != nil || rt.RevokedAt != nil {
+		// кто-то предъявил старый токен: считаем семейство скомпрометированным
+		if err = s.db.RevokeRefreshTokenFamily(txCtx, rt.FamilyID); err != nil {
+			return nil, err
+		}
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(rt.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if err = s.db.MarkRefreshTokenUsed(txCtx, rt.ID); err != nil {
+		return nil, err
+	}
+	user, err := s.db.GetUserById(txCtx, rt.UserID)
+	if err != nil {
+		return nil, err
+	}
+	newToken, err := s.issueRefreshToken(txCtx, rt.UserID, rt.FamilyID, rt.MFA)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.newAuthResponse(ctx, user, newToken, rt.MFA)
+}
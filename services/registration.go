@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+	"work/models"
+)
+
+var (
+	ErrRegistrationDisabled     = NewError(ErrNotFound, "registration_disabled", "регистрация отключена")
+	ErrEmailNotVerified         = NewError(ErrForbidden, "email_not_verified", "адрес электронной почты не подтвержден")
+	ErrInvalidVerificationToken = NewError(ErrValidation, "invalid_verification_token", "недействительная ссылка подтверждения")
+)
+
+// RegistrationConfig настройки самостоятельной регистрации.
+type RegistrationConfig struct {
+	Enabled bool
+	// RequireVerification запрещает вход, пока адрес почты не подтвержден.
+	RequireVerification bool
+	// VerifyURL адрес страницы подтверждения, к нему добавляется ?token=...
+	VerifyURL string
+	TokenTTL  time.Duration
+}
+
+// WithRegistration включает и настраивает самостоятельную регистрацию.
+func WithRegistration(cfg RegistrationConfig) Option {
+	return func(s *UserServiceDb) {
+		if cfg.TokenTTL == 0 {
+			cfg.TokenTTL = 24 * time.Hour
+		}
+		s.registration = cfg
+	}
+}
+
+// noopMailer используется, пока почта не настроена: письма не отправляются.
+type noopMailer struct{}
+
+func (noopMailer) Send(ctx context.Context, msg MailMessage) error {
+	slog.WarnContext(ctx, "почта не настроена, письмо не отправлено", "to", msg.To)
+	return nil
+}
+
+// Register создает учетную запись с ролью user и отправляет письмо для подтверждения адреса.
+func (s *UserServiceDb) Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
+	ctx, span := startSpan(ctx, "Register")
+	defer span.End()
+	if !s.registration.Enabled {
+		return nil, ErrRegistrationDisabled
+	}
+	var v validator
+	s.loginPolicy.check(&v, "login", req.Login)
+	s.passwordPolicy.check(&v, "password", req.Password, req.Login)
+	if v.required("email", req.Email) {
+		checkEmail(&v, "email", req.Email)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hash, err := s.hasher.Hash(req.Password)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{
+		Login:    req.Login,
+		Password: hash,
+		Role:     RoleUser,
+		Email:    &req.Email,
+	}
+	if err = s.db.CreateUser(txCtx, user); err != nil {
+		return nil, err
+	}
+	token, err := s.createVerificationToken(txCtx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// письмо отправляем после фиксации: при ошибке его можно запросить повторно
+	if err = s.sendVerificationMail(ctx, req.Email, token); err != nil {
+		slog.ErrorContext(ctx, "не удалось отправить письмо подтверждения", "email", req.Email, "error", err)
+	}
+	user.Password = ""
+	return user, nil
+}
+
+// VerifyEmail подтверждает адрес по одноразовому токену из письма.
+func (s *UserServiceDb) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := startSpan(ctx, "VerifyEmail")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t, err := s.db.GetEmailVerificationToken(txCtx, hashOpaqueToken(token))
+	if err != nil {
+		return err
+	}
+	if t == nil || t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+	if err = s.db.MarkEmailVerificationTokenUsed(txCtx, t.ID); err != nil {
+		return err
+	}
+	if err = s.db.SetEmailVerified(txCtx, t.UserID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ResendVerification повторно отправляет письмо. Результат не зависит от того,
+// существует ли адрес, чтобы по ответу нельзя было проверять чужие адреса.
+// Запросы писем на один адрес и с одного IP ограничены (MailThrottle).
+func (s *UserServiceDb) ResendVerification(ctx context.Context, email, ip string) error {
+	ctx, span := startSpan(ctx, "ResendVerification")
+	defer span.End()
+	if !s.registration.Enabled {
+		return ErrRegistrationDisabled
+	}
+	if err := s.throttleMail(ctx, email, ip); err != nil {
+		return err
+	}
+	user, err := s.db.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	// токен создается и письмо отправляется в фоне: время ответа одинаково
+	// для существующего и несуществующего адреса
+	if user != nil && !user.EmailVerified {
+		s.goBackground(ctx, func(ctx context.Context) {
+			token, err := s.createVerificationToken(ctx, user.ID)
+			if err == nil {
+				err = s.sendVerificationMail(ctx, email, token)
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "не удалось отправить письмо подтверждения", "email", email, "error", err)
+			}
+		})
+	}
+	return nil
+}
+
+func (s *UserServiceDb) createVerificationToken(ctx context.Context, userID int) (string, error) {
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.db.CreateEmailVerificationToken(ctx, &models.UserToken{
+		UserID:    userID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.registration.TokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *UserServiceDb) sendVerificationMail(ctx context.Context, to, token string) error {
+	return s.mailer.Send(ctx, MailMessage{
+		To:      to,
+		Subject: "Подтверждение адреса электронной почты",
+		Body: fmt.Sprintf("Для завершения регистрации перейдите по ссылке:\n%s\n\nСсылка действует %s.\n",
+			tokenLink(s.registration.VerifyURL, token), s.registration.TokenTTL),
+	})
+}
+
+// tokenLink добавляет токен к адресу страницы; без адреса в письмо попадает сам токен.
+func tokenLink(base, token string) string {
+	u, err := url.Parse(base)
+	if base == "" || err != nil {
+		return token
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
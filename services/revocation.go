@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+	"work/models"
+)
+
+// RevocationCacheTTL — сколько хранится в памяти результат проверки в базе.
+// Отзыв на этой же реплике виден сразу, на остальных — не позже чем через TTL.
+const RevocationCacheTTL = 10 * time.Second
+
+// RevocationStore проверяет и отзывает выпущенные JWT.
+// Данные хранятся в Postgres, результаты проверок кэшируются в памяти.
+type RevocationStore struct {
+	db  Storage
+	ttl time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]revokedTokenEntry // jti -> отозван ли
+	users  map[int]userRevocationEntry  // id пользователя -> граница валидности
+}
+
+type revokedTokenEntry struct {
+	revoked   bool
+	checkedAt time.Time
+}
+
+type userRevocationEntry struct {
+	validAfter time.Time
+	exists     bool
+	checkedAt  time.Time
+}
+
+func NewRevocationStore(db Storage, ttl time.Duration) *RevocationStore {
+	return &RevocationStore{
+		db:     db,
+		ttl:    ttl,
+		tokens: make(map[string]revokedTokenEntry),
+		users:  make(map[int]userRevocationEntry),
+	}
+}
+
+// IsRevoked сообщает, что токен отозван явно (logout), выпущен до отзыва
+// всех сессий пользователя (смена роли, пароля) или пользователь удален.
+func (r *RevocationStore) IsRevoked(ctx context.Context, claims *models.JwtUser) (bool, error) {
+	if claims.ID != "" {
+		revoked, err := r.isTokenRevoked(ctx, claims.ID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	validAfter, exists, err := r.userValidAfter(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return true, nil
+	}
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+	// iat хранится с точностью до секунды
+	return claims.IssuedAt.Time.Before(validAfter.Truncate(time.Second)), nil
+}
+
+func (r *RevocationStore) isTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	now := time.Now()
+	r.mu.Lock()
+	entry, ok := r.tokens[jti]
+	r.mu.Unlock()
+	if ok && (entry.revoked || now.Sub(entry.checkedAt) < r.ttl) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := r.db.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	r.tokens[jti] = revokedTokenEntry{revoked: revoked, checkedAt: now}
+	r.purgeLocked(now)
+	r.mu.Unlock()
+	return revoked, nil
+}
+
+func (r *RevocationStore) userValidAfter(ctx context.Context, userID int) (time.Time, bool, error) {
+	now := time.Now()
+	r.mu.Lock()
+	entry, ok := r.users[userID]
+	r.mu.Unlock()
+	if ok && now.Sub(entry.checkedAt) < r.ttl {
+		return entry.validAfter, entry.exists, nil
+	}
+
+	validAfter, err := r.db.GetTokensValidAfter(ctx, userID)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	entry = userRevocationEntry{exists: validAfter != nil, checkedAt: now}
+	if validAfter != nil {
+		entry.validAfter = *validAfter
+	}
+	r.mu.Lock()
+	r.users[userID] = entry
+	r.mu.Unlock()
+	return entry.validAfter, entry.exists, nil
+}
+
+// purgeLocked удаляет устаревшие записи кэша, чтобы он не рос бесконечно.
+func (r *RevocationStore) purgeLocked(now time.Time) {
+	for jti, entry := range r.tokens {
+		// отозванные токены живут не дольше access токена
+		if now.Sub(entry.checkedAt) > AccessTokenTTL {
+			delete(r.tokens, jti)
+		}
+	}
+	for id, entry := range r.users {
+		if now.Sub(entry.checkedAt) > r.ttl {
+			delete(r.users, id)
+		}
+	}
+}
+
+// RevokeToken отзывает один access токен до истечения его срока.
+func (r *RevocationStore) RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	if err := r.db.RevokeToken(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.tokens[jti] = revokedTokenEntry{revoked: true, checkedAt: time.Now()}
+	r.mu.Unlock()
+	return nil
+}
+
+// RevokeUser делает недействительными все access токены пользователя, выпущенные до текущего момента.
+func (r *RevocationStore) RevokeUser(ctx context.Context, userID int) error {
+	now := time.Now()
+	if err := r.db.SetTokensValidAfter(ctx, userID, now); err != nil {
+		return err
+	}
+	r.Forget(userID)
+	return nil
+}
+
+// Forget сбрасывает кэш пользователя, например после его удаления.
+func (r *RevocationStore) Forget(userID int) {
+	r.mu.Lock()
+	delete(r.users, userID)
+	r.mu.Unlock()
+}
+
+// ForgetAll сбрасывает кэш всех пользователей, например после изменения прав роли.
+func (r *RevocationStore) ForgetAll() {
+	r.mu.Lock()
+	clear(r.users)
+	r.mu.Unlock()
+}
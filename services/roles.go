@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"time"
+	"work/models"
+)
+
+var (
+	ErrUnknownRole       = NewFieldError("role", "unknown_role", "роль не существует")
+	ErrUnknownPermission = NewFieldError("permissions", "unknown_permission", "право не существует")
+	ErrRoleNotFound      = NewError(ErrNotFound, "role_not_found", "роль не найдена")
+	ErrRoleExists        = NewError(ErrConflict, "role_exists", "роль уже существует")
+	ErrRoleInUse         = NewError(ErrConflict, "role_in_use", "роль назначена пользователям")
+	ErrProtectedRole     = NewError(ErrValidation, "protected_role", "встроенную роль нельзя удалить")
+)
+
+// Встроенные роли: admin создается миграцией, user назначается по умолчанию.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+func (s *UserServiceDb) ListRoles(ctx context.Context) ([]models.Role, error) {
+	ctx, span := startSpan(ctx, "ListRoles")
+	defer span.End()
+	return s.db.ListRoles(ctx)
+}
+
+func (s *UserServiceDb) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	ctx, span := startSpan(ctx, "ListPermissions")
+	defer span.End()
+	return s.db.ListPermissions(ctx)
+}
+
+func (s *UserServiceDb) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	ctx, span := startSpan(ctx, "GetRole")
+	defer span.End()
+	role, err := s.db.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func (s *UserServiceDb) CreateRole(ctx context.Context, role *models.Role) error {
+	ctx, span := startSpan(ctx, "CreateRole")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	existing, err := s.db.GetRole(txCtx, role.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrRoleExists
+	}
+	if err = s.validatePermissions(txCtx, role.Permissions); err != nil {
+		return err
+	}
+	if err = s.db.CreateRole(txCtx, role); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateRole заменяет описание и права роли. Токены пользователей роли отзываются,
+// чтобы новые права вступили в силу при следующем обновлении токена.
+func (s *UserServiceDb) UpdateRole(ctx context.Context, role *models.Role) error {
+	ctx, span := startSpan(ctx, "UpdateRole")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	existing, err := s.db.GetRole(txCtx, role.Name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrRoleNotFound
+	}
+	if err = s.validatePermissions(txCtx, role.Permissions); err != nil {
+		return err
+	}
+	if err = s.db.UpdateRole(txCtx, role); err != nil {
+		return err
+	}
+	if !slices.Equal(sorted(existing.Permissions), sorted(role.Permissions)) {
+		if err = s.db.RevokeRoleTokens(txCtx, role.Name, time.Now()); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	s.revocations.ForgetAll()
+	return nil
+}
+
+func (s *UserServiceDb) DeleteRole(ctx context.Context, name string) error {
+	ctx, span := startSpan(ctx, "DeleteRole")
+	defer span.End()
+	if name == RoleAdmin || name == RoleUser {
+		return ErrProtectedRole
+	}
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	existing, err := s.db.GetRole(txCtx, name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrRoleNotFound
+	}
+	count, err := s.db.CountUsersWithRole(txCtx, name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+	if err = s.db.DeleteRole(txCtx, name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// validateRole проверяет, что назначаемая роль существует.
+func (s *UserServiceDb) validateRole(ctx context.Context, name string) error {
+	role, err := s.db.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return ErrUnknownRole
+	}
+	return nil
+}
+
+func (s *UserServiceDb) validatePermissions(ctx context.Context, perms []string) error {
+	known, err := s.db.ListPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range perms {
+		if !slices.ContainsFunc(known, func(k models.Permission) bool { return k.Name == p }) {
+			return ErrUnknownPermission
+		}
+	}
+	return nil
+}
+
+// rolePermissions права роли для записи в токен.
+func (s *UserServiceDb) rolePermissions(ctx context.Context, name string) ([]string, error) {
+	role, err := s.db.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+	return role.Permissions, nil
+}
+
+func sorted(values []string) []string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+	"work/models"
+)
+
+// memStorage хранилище в памяти для тестов сервиса: пользователи, счетчики попыток
+// входа и коды восстановления. Остальные методы Storage не вызываются.
+type memStorage struct {
+	Storage
+
+	mu            sync.Mutex
+	users         map[int]*models.User
+	attempts      map[string]*models.LoginAttempt
+	recoveryCodes map[string]bool // хэш кода → уже использован
+	passwordSets  int             // вызовы UpdatePassword
+}
+
+func newMemStorage(users ...*models.User) *memStorage {
+	m := &memStorage{
+		users:         make(map[int]*models.User),
+		attempts:      make(map[string]*models.LoginAttempt),
+		recoveryCodes: make(map[string]bool),
+	}
+	for _, u := range users {
+		m.users[u.ID] = u
+	}
+	return m
+}
+
+func (m *memStorage) GetUserByLogin(_ context.Context, login string) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.users {
+		if u.Login == login {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (m *memStorage) GetUserById(_ context.Context, id int) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	copied := *u
+	return &copied, nil
+}
+
+func (m *memStorage) UpdatePassword(_ context.Context, id int, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	u.Password = hash
+	m.passwordSets++
+	return nil
+}
+
+func (m *memStorage) GetLoginAttempt(_ context.Context, scope, key string) (*models.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.attempts[scope+"/"+key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (m *memStorage) RecordLoginFailure(_ context.Context, scope, key string, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	a, ok := m.attempts[scope+"/"+key]
+	if !ok {
+		a = &models.LoginAttempt{Scope: scope, Key: key}
+		m.attempts[scope+"/"+key] = a
+	}
+	if a.LastFailureAt.Before(now.Add(-window)) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	return a.Failures, nil
+}
+
+func (m *memStorage) SetLoginLockedUntil(_ context.Context, scope, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if a, ok := m.attempts[scope+"/"+key]; ok {
+		a.LockedUntil = &until
+	}
+	return nil
+}
+
+func (m *memStorage) ResetLoginAttempts(_ context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attempts, scope+"/"+key)
+	return nil
+}
+
+func (m *memStorage) AdvanceMFAStep(_ context.Context, userID int, step int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[userID]
+	if !ok || (u.MFALastStep != nil && *u.MFALastStep >= step) {
+		return false, nil
+	}
+	u.MFALastStep = &step
+	return true, nil
+}
+
+func (m *memStorage) UseRecoveryCode(_ context.Context, _ int, hash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	used, ok := m.recoveryCodes[hash]
+	if !ok || used {
+		return false, nil
+	}
+	m.recoveryCodes[hash] = true
+	return true, nil
+}
+
+func (m *memStorage) ReplaceRecoveryCodes(_ context.Context, _ int, hashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recoveryCodes = make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		m.recoveryCodes[h] = false
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"work/models"
+)
+
+// rfc6238Secret ключ из тестовых векторов RFC 6238 ("12345678901234567890") в base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		now      int64
+		wantStep int64
+		wantOK   bool
+	}{
+		// RFC 6238, SHA1: последние шесть цифр восьмизначных кодов
+		{"RFC 6238 T=59", rfc6238Secret, "287082", 59, 1, true},
+		{"RFC 6238 T=1111111109", rfc6238Secret, "081804", 1111111109, 37037036, true},
+		{"RFC 6238 T=1234567890", rfc6238Secret, "005924", 1234567890, 41152263, true},
+		{"секрет в нижнем регистре", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "005924", 1234567890, 41152263, true},
+		{"код с пробелом", rfc6238Secret, "005 924", 1234567890, 41152263, true},
+		{"предыдущий шаг", rfc6238Secret, "005924", 1234567890 + totpPeriod, 41152263, true},
+		{"следующий шаг", rfc6238Secret, "005924", 1234567890 - totpPeriod, 41152263, true},
+		{"вне допустимого расхождения", rfc6238Secret, "005924", 1234567890 + 2*totpPeriod, 0, false},
+		{"неверный код", rfc6238Secret, "005925", 1234567890, 0, false},
+		{"короткий код", rfc6238Secret, "05924", 1234567890, 0, false},
+		{"некорректный секрет", "not base32!", "005924", 1234567890, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.now, 0))
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Fatalf("ValidateTOTP = %d, %v; ожидалось %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	ctx := context.Background()
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Unix() / totpPeriod
+	current := totpCode(key, now)
+	previous := totpCode(key, now-1)
+
+	storage := newMemStorage(&models.User{ID: 1, Login: "alice", MFASecret: &secret, MFAEnabled: true})
+	s := NewUserService(storage)
+	codes, err := s.replaceRecoveryCodes(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodesCount {
+		t.Fatalf("выдано %d кодов восстановления, ожидалось %d", len(codes), recoveryCodesCount)
+	}
+
+	// шаги выполняются по порядку: коды одноразовые
+	steps := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"текущий код TOTP", current, true},
+		{"тот же код повторно", current, false},
+		{"код предыдущего шага после текущего", previous, false},
+		{"код восстановления", codes[0], true},
+		{"тот же код восстановления повторно", codes[0], false},
+		{"код восстановления в нижнем регистре без дефиса", strings.ToLower(strings.ReplaceAll(codes[1], "-", "")), true},
+		{"неизвестный код восстановления", "AAAAA-AAAAA", false},
+		{"пустой код", "", false},
+	}
+	for _, step := range steps {
+		user, err := storage.GetUserById(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := s.verifySecondFactor(ctx, user, step.code)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if ok != step.want {
+			t.Fatalf("%s: принят = %v, ожидалось %v", step.name, ok, step.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"work/models"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+var (
+	ErrInvalidCursor = NewFieldError("cursor", "invalid_cursor", "некорректный курсор")
+	ErrInvalidSort   = NewFieldError("sort", "invalid_sort", "недопустимое поле сортировки")
+	ErrInvalidLimit  = NewFieldError("limit", "invalid_limit", "недопустимый размер страницы")
+)
+
+// userCursor позиция последней записи страницы. Сортировка хранится в курсоре,
+// чтобы курсор нельзя было применить к выборке с другим порядком.
+type userCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    int    `json:"id"`
+}
+
+func encodeCursor(c userCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (userCursor, error) {
+	var c userCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err = json.Unmarshal(b, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// ListUsers возвращает страницу пользователей с фильтрами, сортировкой и курсором на следующую страницу.
+func (s *UserServiceDb) ListUsers(ctx context.Context, q models.UserListQuery) (*models.UserPage, error) {
+	ctx, span := startSpan(ctx, "ListUsers")
+	defer span.End()
+	if q.Limit == 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit < 0 || q.Limit > MaxPageSize {
+		return nil, ErrInvalidLimit
+	}
+	if q.Sort == "" {
+		q.Sort = "login"
+	}
+	filter := models.UserListFilter{
+		Role:        q.Role,
+		LoginPrefix: q.LoginPrefix,
+		SortField:   strings.TrimPrefix(q.Sort, "-"),
+		Desc:        strings.HasPrefix(q.Sort, "-"),
+		Limit:       q.Limit + 1, // лишняя запись показывает, есть ли следующая страница
+	}
+	switch filter.SortField {
+	case "login", "id", "role":
+	default:
+		return nil, ErrInvalidSort
+	}
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != q.Sort {
+			return nil, ErrInvalidCursor
+		}
+		filter.HasAfter, filter.AfterValue, filter.AfterID = true, c.Value, c.ID
+	}
+
+	users, err := s.db.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.db.CountUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.UserPage{Items: users, Total: total}
+	if len(users) > q.Limit {
+		page.Items = users[:q.Limit]
+		last := page.Items[q.Limit-1]
+		c := userCursor{Sort: q.Sort, ID: last.ID}
+		switch filter.SortField {
+		case "login":
+			c.Value = last.Login
+		case "role":
+			c.Value = last.Role
+		}
+		page.NextCursor = encodeCursor(c)
+	}
+	return page, nil
+}
@@ -2,61 +2,148 @@ package services
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"strings"
+	"log/slog"
+	"sync"
+	"time"
 	"work/models"
 )
 
 type UserServiceDb struct {
-	db Storage
+	db           Storage
+	hasher       PasswordHasher
+	revocations  *RevocationStore
+	lockout      LockoutPolicy
+	mfaRoles     []string
+	mailer       Mailer
+	registration RegistrationConfig
+	reset        PasswordResetConfig
+	mailThrottle MailThrottle
+
+	loginPolicy    LoginPolicy
+	passwordPolicy PasswordPolicy
+
+	// dummyHash хэш случайного пароля для входа с несуществующим логином:
+	// проверка занимает столько же времени, и по нему не узнать, есть ли логин
+	dummyHash func() string
+
+	// background фоновые задачи после ответа на запрос (письма), см. goBackground
+	background sync.WaitGroup
 }
 
-func NewUserService(db Storage) *UserServiceDb {
-	return &UserServiceDb{db: db}
+// Option настраивает UserServiceDb при создании.
+type Option func(*UserServiceDb)
+
+// WithPasswordHasher задает алгоритм хэширования новых паролей.
+func WithPasswordHasher(h PasswordHasher) Option {
+	return func(s *UserServiceDb) {
+		s.hasher = h
+	}
+}
+
+func NewUserService(db Storage, opts ...Option) *UserServiceDb {
+	s := &UserServiceDb{
+		db:           db,
+		hasher:       NewArgon2idHasher(),
+		revocations:  NewRevocationStore(db, RevocationCacheTTL),
+		lockout:      DefaultLockoutPolicy(),
+		mailer:       noopMailer{},
+		reset:        PasswordResetConfig{TokenTTL: time.Hour},
+		mailThrottle: DefaultMailThrottle(),
+
+		loginPolicy:    DefaultLoginPolicy(),
+		passwordPolicy: DefaultPasswordPolicy(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.dummyHash = sync.OnceValue(func() string {
+		password, _ := newRandomID()
+		hash, _ := s.hasher.Hash(password)
+		return hash
+	})
+	return s
 }
 
-//метод авторизации
+//метод авторизации, ip — адрес клиента для защиты от перебора
 
-func (s *UserServiceDb) Authenticate(ctx context.Context, login, password string) (*models.User, error) {
+func (s *UserServiceDb) Authenticate(ctx context.Context, login, password, ip string) (user *models.User, err error) {
+	ctx, span := startSpan(ctx, "Authenticate")
+	defer span.End()
+	defer func() { observeLogin("password", err) }()
+	if err = s.checkLockout(ctx, login, ip); err != nil {
+		return nil, err
+	}
 
-	user, err := s.db.GetUserByLogin(ctx, login)
+	user, err = s.db.GetUserByLogin(ctx, login)
+	if errors.Is(err, ErrNotFound) {
+		_, _, _ = VerifyPassword(s.hasher, password, s.dummyHash())
+		if lockErr := s.registerFailure(ctx, login, ip); lockErr != nil {
+			return nil, lockErr
+		}
+		return nil, ErrInvalidCredentials
+	}
 	if err != nil {
 		return nil, err
 	}
-	if user == nil {
-		return nil, errors.New("Пользователь не найден")
+
+	// Проверяем пароль; хэширование — заметная часть времени входа, поэтому отдельный span
+	_, hashSpan := tracer.Start(ctx, "VerifyPassword")
+	ok, rehash, err := VerifyPassword(s.hasher, password, user.Password)
+	hashSpan.End()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if lockErr := s.registerFailure(ctx, login, ip); lockErr != nil {
+			return nil, lockErr
+		}
+		return nil, ErrInvalidCredentials
+	}
+	s.resetFailures(ctx, login)
+	if s.registration.RequireVerification && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
 	}
 
-	// Проверяем пароль
-	hashedPassword := HashPassword(password)
-	if user.Password != hashedPassword {
-		return nil, errors.New("неверный пароль")
+	// Прозрачно обновляем устаревший хэш (например, SHA-256 без соли)
+	if rehash {
+		if newHash, err := s.hasher.Hash(password); err == nil {
+			if err = s.db.UpdatePassword(ctx, user.ID, newHash); err != nil {
+				slog.ErrorContext(ctx, "не удалось обновить хэш пароля", "user", user.ID, "error", err)
+			} else {
+				user.Password = newHash
+			}
+		}
 	}
 	return user, nil
 }
 
-func (s *UserServiceDb) GetAllUsers(ctx context.Context) ([]models.AllUser, error) {
-	return s.db.GetAllUsers(ctx)
-}
-
 func (s *UserServiceDb) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, span := startSpan(ctx, "CreateUser")
+	defer span.End()
+	if err := s.ValidateUser(user, true); err != nil {
+		return err
+	}
 	tx, txCtx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback() // откат, если не сделан Commit
 	// Хэшируем пароль
-	user.Password = HashPassword(user.Password)
+	user.Password, err = s.hasher.Hash(user.Password)
+	if err != nil {
+		return err
+	}
 	if user.Role == "" {
-		user.Role = "user"
+		user.Role = RoleUser
+	}
+	if err = s.validateRole(txCtx, user.Role); err != nil {
+		return err
 	}
+	// адрес, указанный администратором, не требует подтверждения
+	user.EmailVerified = true
 	err = s.db.CreateUser(txCtx, user)
 	if err != nil {
-		if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
-			return errors.New("пользователь уже существует")
-		}
-		tx.Rollback()
 		return err
 	}
 
@@ -64,13 +151,18 @@ func (s *UserServiceDb) CreateUser(ctx context.Context, user *models.User) error
 }
 
 func (s *UserServiceDb) UpdateUser(ctx context.Context, user *models.User) error {
+	ctx, span := startSpan(ctx, "UpdateUser")
+	defer span.End()
+	if err := s.ValidateUser(user, false); err != nil {
+		return err
+	}
 	tx, txCtx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 	currentUser, err := s.db.GetUserById(txCtx, user.ID)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+	if err != nil {
 		return err
 	}
 	if user.Login == "" {
@@ -78,23 +170,50 @@ func (s *UserServiceDb) UpdateUser(ctx context.Context, user *models.User) error
 	}
 	if user.Role == "" {
 		user.Role = currentUser.Role
+	} else if err = s.validateRole(txCtx, user.Role); err != nil {
+		return err
 	}
+	if user.Email == nil {
+		user.Email = currentUser.Email
+	}
+	passwordChanged := user.Password != ""
 
 	//проверка пароль изменен или нет.
 
-	if user.Password == "" {
+	// требование сменить пароль задается вместе с новым паролем, иначе сохраняется
+	if !passwordChanged {
 		user.Password = currentUser.Password
+		user.MustChangePassword = currentUser.MustChangePassword
 	} else {
-		user.Password = HashPassword(user.Password)
+		user.Password, err = s.hasher.Hash(user.Password)
+		if err != nil {
+			return err
+		}
 	}
 	err = s.db.UpdateUser(txCtx, user)
 	if err != nil {
 		return err
 	}
-	return tx.Commit()
+	// токены со старой ролью или выпущенные по старому паролю больше не действуют
+	if passwordChanged {
+		if err = s.revokeUserSessions(txCtx, user.ID); err != nil {
+			return err
+		}
+	} else if user.Role != currentUser.Role {
+		if err = s.revocations.RevokeUser(txCtx, user.ID); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	s.revocations.Forget(user.ID)
+	return nil
 }
 
 func (s *UserServiceDb) DeleteUser(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "DeleteUser")
+	defer span.End()
 	tx, txCtx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -104,6 +223,58 @@ func (s *UserServiceDb) DeleteUser(ctx context.Context, id int) error {
 	if err != nil {
 		return err
 	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	s.revocations.Forget(id)
+	return nil
+}
 
-	return tx.Commit()
+// Logout отзывает текущий access токен и, если передан, refresh токен вместе с его семейством.
+func (s *UserServiceDb) Logout(ctx context.Context, claims *models.JwtUser, refreshToken string) error {
+	ctx, span := startSpan(ctx, "Logout")
+	defer span.End()
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := s.revocations.RevokeToken(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+			return err
+		}
+	}
+	if refreshToken == "" {
+		return nil
+	}
+	rt, err := s.db.GetRefreshTokenByHash(ctx, hashOpaqueToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	// чужой или неизвестный refresh токен молча игнорируем
+	if rt == nil || rt.UserID != claims.UserID {
+		return nil
+	}
+	return s.db.RevokeRefreshTokenFamily(ctx, rt.FamilyID)
+}
+
+// RevokeUserSessions завершает все сессии пользователя: access и refresh токены.
+func (s *UserServiceDb) RevokeUserSessions(ctx context.Context, userID int) error {
+	ctx, span := startSpan(ctx, "RevokeUserSessions")
+	defer span.End()
+	tx, txCtx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = s.revokeUserSessions(txCtx, userID); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	s.revocations.Forget(userID)
+	return nil
+}
+
+func (s *UserServiceDb) revokeUserSessions(ctx context.Context, userID int) error {
+	if err := s.revocations.RevokeUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.db.RevokeUserRefreshTokens(ctx, userID)
 }
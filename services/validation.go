@@ -0,0 +1,292 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"work/models"
+)
+
+// LoginPolicy ограничения на логин. MaxLength не больше размера колонки users.login.
+type LoginPolicy struct {
+	MinLength int
+	MaxLength int
+	Pattern   *regexp.Regexp
+}
+
+func DefaultLoginPolicy() LoginPolicy {
+	return LoginPolicy{
+		MinLength: 3,
+		MaxLength: 50,
+		Pattern:   regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`),
+	}
+}
+
+// PasswordPolicy требования к новым паролям. На вход с существующим паролем не влияют.
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// MinClasses сколько разных классов символов нужно: строчные и заглавные буквы, цифры, прочие.
+	MinClasses int
+	// DenyList запрещенные пароли в нижнем регистре.
+	DenyList map[string]struct{}
+}
+
+//go:embed common_passwords.txt
+var commonPasswords []byte
+
+func DefaultPasswordPolicy() PasswordPolicy {
+	deny, _ := readDenyList(bytes.NewReader(commonPasswords))
+	return PasswordPolicy{
+		MinLength:  8,
+		MaxLength:  128,
+		MinClasses: 2,
+		DenyList:   deny,
+	}
+}
+
+// NewPasswordPolicy политика по умолчанию с заданными минимальной длиной и числом классов
+// символов; denyListFile, если задан, дополняет встроенный список запрещенных паролей.
+// Ошибка, если политике не может соответствовать ни один пароль.
+func NewPasswordPolicy(minLength, minClasses int, denyListFile string) (PasswordPolicy, error) {
+	policy := DefaultPasswordPolicy()
+	if minLength < 1 || minLength > policy.MaxLength {
+		return policy, fmt.Errorf("минимальная длина пароля должна быть от 1 до %d", policy.MaxLength)
+	}
+	if minClasses < 1 || minClasses > 4 {
+		return policy, errors.New("число классов символов в пароле должно быть от 1 до 4")
+	}
+	policy.MinLength = minLength
+	policy.MinClasses = minClasses
+	if denyListFile != "" {
+		if err := policy.LoadPasswordDenyList(denyListFile); err != nil {
+			return policy, err
+		}
+	}
+	return policy, nil
+}
+
+// LoadPasswordDenyList добавляет к политике пароли из файла: по одному в строке, # — комментарий.
+func (p *PasswordPolicy) LoadPasswordDenyList(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	deny, err := readDenyList(f)
+	if err != nil {
+		return err
+	}
+	if p.DenyList == nil {
+		p.DenyList = make(map[string]struct{}, len(deny))
+	}
+	for password := range deny {
+		p.DenyList[password] = struct{}{}
+	}
+	return nil
+}
+
+func readDenyList(r io.Reader) (map[string]struct{}, error) {
+	deny := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		deny[strings.ToLower(line)] = struct{}{}
+	}
+	return deny, scanner.Err()
+}
+
+// WithLoginPolicy задает ограничения на логин.
+func WithLoginPolicy(p LoginPolicy) Option {
+	return func(s *UserServiceDb) {
+		s.loginPolicy = p
+	}
+}
+
+// WithPasswordPolicy задает требования к новым паролям.
+func WithPasswordPolicy(p PasswordPolicy) Option {
+	return func(s *UserServiceDb) {
+		s.passwordPolicy = p
+	}
+}
+
+// GeneratePassword случайный пароль, который проходит политику паролей сервиса.
+func (s *UserServiceDb) GeneratePassword() (string, error) {
+	return s.passwordPolicy.GeneratePassword()
+}
+
+// validator собирает ошибки всех полей запроса, чтобы вернуть их одним ответом.
+type validator struct {
+	fields []models.FieldError
+}
+
+func (v *validator) add(field, code, message string, params map[string]string) {
+	v.fields = append(v.fields, models.FieldError{Field: field, Code: code, Message: message, Params: params})
+}
+
+// required проверяет, что значение не пустое; false — поле пустое и проверять его дальше не нужно.
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "required", "обязательное поле", nil)
+		return false
+	}
+	return true
+}
+
+func (v *validator) maxLength(field, value string, max int) bool {
+	if max > 0 && utf8.RuneCountInString(value) > max {
+		v.add(field, "too_long", "не более "+strconv.Itoa(max)+" символов",
+			map[string]string{"max": strconv.Itoa(max)})
+		return false
+	}
+	return true
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return NewValidationError(v.fields...)
+}
+
+func (p LoginPolicy) check(v *validator, field, login string) {
+	if !v.required(field, login) || !v.maxLength(field, login, p.MaxLength) {
+		return
+	}
+	if utf8.RuneCountInString(login) < p.MinLength {
+		v.add(field, "too_short", "не менее "+strconv.Itoa(p.MinLength)+" символов",
+			map[string]string{"min": strconv.Itoa(p.MinLength)})
+		return
+	}
+	if p.Pattern != nil && !p.Pattern.MatchString(login) {
+		v.add(field, "invalid_chars", "допустимы латинские буквы, цифры и символы . _ -", nil)
+	}
+}
+
+func (p PasswordPolicy) check(v *validator, field, password, login string) {
+	if !v.required(field, password) || !v.maxLength(field, password, p.MaxLength) {
+		return
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		v.add(field, "too_short", "не менее "+strconv.Itoa(p.MinLength)+" символов",
+			map[string]string{"min": strconv.Itoa(p.MinLength)})
+		return
+	}
+	if classes := passwordClasses(password); classes < p.MinClasses {
+		v.add(field, "too_weak", "нужны символы не менее "+strconv.Itoa(p.MinClasses)+
+			" разных типов: строчные и заглавные буквы, цифры, прочие символы",
+			map[string]string{"classes": strconv.Itoa(p.MinClasses)})
+		return
+	}
+	lower := strings.ToLower(password)
+	if _, ok := p.DenyList[lower]; ok {
+		v.add(field, "too_common", "пароль слишком распространен", nil)
+		return
+	}
+	if login != "" && strings.Contains(lower, strings.ToLower(login)) {
+		v.add(field, "contains_login", "пароль не должен содержать логин", nil)
+	}
+}
+
+// GeneratePassword случайный пароль, который проходит политику: все четыре класса символов
+// и длина не меньше MinLength (но не короче 20 символов).
+func (p PasswordPolicy) GeneratePassword() (string, error) {
+	const alphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789-_.!@#%" // 64 символа: без смещения по модулю
+	buf := make([]byte, max(20, p.MinLength))
+	for {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for i, b := range buf {
+			buf[i] = alphabet[int(b)%len(alphabet)]
+		}
+		if passwordClasses(string(buf)) == 4 {
+			return string(buf), nil
+		}
+	}
+}
+
+// passwordClasses число классов символов в пароле.
+func passwordClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, ok := range []bool{lower, upper, digit, other} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+func checkEmail(v *validator, field, email string) {
+	if !v.maxLength(field, email, 255) {
+		return
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		v.add(field, "invalid_email", "некорректный адрес электронной почты", nil)
+	}
+}
+
+// ValidateUser проверяет данные пользователя от администратора. При изменении
+// (create = false) пустые логин и пароль означают «не менять».
+func (s *UserServiceDb) ValidateUser(user *models.User, create bool) error {
+	var v validator
+	if create || user.Login != "" {
+		s.loginPolicy.check(&v, "login", user.Login)
+	}
+	if create || user.Password != "" {
+		s.passwordPolicy.check(&v, "password", user.Password, user.Login)
+	}
+	if user.Email != nil && *user.Email != "" {
+		checkEmail(&v, "email", *user.Email)
+	}
+	v.maxLength("role", user.Role, 50)
+	return v.err()
+}
+
+// ValidateLoginRequest проверяет форму запроса входа. Политика паролей здесь не применяется:
+// старые пароли могут ей не соответствовать, ограничивается только размер.
+func (s *UserServiceDb) ValidateLoginRequest(req *models.LoginRequest) error {
+	var v validator
+	if v.required("login", req.Login) {
+		v.maxLength("login", req.Login, s.loginPolicy.MaxLength)
+	}
+	if v.required("password", req.Password) {
+		v.maxLength("password", req.Password, s.passwordPolicy.MaxLength)
+	}
+	return v.err()
+}
+
+// validateNewPassword проверяет новый пароль при смене или сбросе.
+func (s *UserServiceDb) validateNewPassword(field, password, login string) error {
+	var v validator
+	s.passwordPolicy.check(&v, field, password, login)
+	return v.err()
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNewPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		minLength  int
+		minClasses int
+		wantErr    bool
+	}{
+		{"по умолчанию", 8, 2, false},
+		{"наименьшие значения", 1, 1, false},
+		{"наибольшие значения", 128, 4, false},
+		{"нулевая длина", 0, 2, true},
+		{"длина больше MaxLength", 129, 2, true},
+		{"нет классов", 8, 0, true},
+		{"классов больше четырех", 8, 5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPasswordPolicy(tt.minLength, tt.minClasses, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка %v, ожидалась: %v", err, tt.wantErr)
+			}
+			if err == nil && (policy.MinLength != tt.minLength || policy.MinClasses != tt.minClasses) {
+				t.Fatalf("политика %+v", policy)
+			}
+		})
+	}
+
+	t.Run("файл запрещенных паролей", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "deny.txt")
+		if err := os.WriteFile(path, []byte("# комментарий\n\nCompany-2024\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		policy, err := NewPasswordPolicy(8, 2, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := policy.DenyList["company-2024"]; !ok {
+			t.Fatal("пароль из файла не добавлен")
+		}
+		if _, ok := policy.DenyList["password"]; !ok {
+			t.Fatal("встроенный список потерян")
+		}
+		if _, err := NewPasswordPolicy(8, 2, filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+			t.Fatal("отсутствующий файл не дал ошибки")
+		}
+	})
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	policy.DenyList["company-2024"] = struct{}{}
+	tests := []struct {
+		name     string
+		password string
+		login    string
+		wantCode string // "" — пароль подходит
+	}{
+		{"подходит", "Correct-Horse-42", "alice", ""},
+		{"два класса", "correcthorse42", "alice", ""},
+		{"пустой", "", "alice", "required"},
+		{"пробелы", "        ", "alice", "required"},
+		{"короткий", "Ab1-", "alice", "too_short"},
+		{"длина в символах, а не байтах", "пароль1", "alice", "too_short"},
+		{"слишком длинный", strings.Repeat("Ab1-", 33), "alice", "too_long"},
+		{"один класс", "correcthorse", "alice", "too_weak"},
+		{"из встроенного списка", "Password1", "alice", "too_common"},
+		{"из списка без учета регистра", "COMPANY-2024", "alice", "too_common"},
+		{"содержит логин", "xxAlice-2024", "alice", "contains_login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v validator
+			policy.check(&v, "password", tt.password, tt.login)
+			err := v.err()
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("пароль отклонен: %v", err)
+				}
+				return
+			}
+			var domainErr *Error
+			if !errors.As(err, &domainErr) || len(domainErr.Fields) != 1 {
+				t.Fatalf("ошибка %v, ожидалась ошибка поля", err)
+			}
+			if got := domainErr.Fields[0]; got.Field != "password" || got.Code != tt.wantCode {
+				t.Fatalf("поле %s, код %s; ожидался код %s", got.Field, got.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	for _, minLength := range []int{8, 20, 64} {
+		policy, err := NewPasswordPolicy(minLength, 4, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		password, err := policy.GeneratePassword()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := utf8.RuneCountInString(password); n != max(20, minLength) {
+			t.Fatalf("длина %d при MinLength %d", n, minLength)
+		}
+		var v validator
+		policy.check(&v, "password", password, "")
+		if err := v.err(); err != nil {
+			t.Fatalf("сгенерированный пароль %q не проходит политику: %v", password, err)
+		}
+	}
+}
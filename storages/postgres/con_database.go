@@ -5,14 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
-	"os"
-	"strings"
+	"log/slog"
 	"work/models"
 	"work/services"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type contextKey string
@@ -34,22 +33,20 @@ type Storage struct {
 	db *sqlx.DB
 }
 
-func NewConnection() (*Storage, error) {
-	dbConnStr := os.Getenv("DATABASE_URL") //проверка переменной в docker
-	if dbConnStr == "" {                   //если пустой, то вручную задаем
-		dbConnStr = "postgresql://postgres:postgres@db:5432/workspace?sslmode=disable"
-	}
-	db, err := sqlx.Open("postgres", dbConnStr) //используем библиотеку postgres
+// NewConnection подключается к базе по адресу dbURL (database.url в конфигурации).
+func NewConnection(dbURL string) (*Storage, error) {
+	db, err := sqlx.Open("postgres", dbURL) //используем библиотеку postgres
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	// Проверка соединения
 	err = db.Ping()
 	if err != nil {
-		log.Fatal(err)
+		db.Close()
+		return nil, err
 	}
-	fmt.Println("DB Connected...")
+	slog.Info("DB connected")
 	return &Storage{db: db}, nil
 }
 
@@ -58,17 +55,22 @@ func (s *Storage) Close() error {
 }
 
 // BeginTx начинает транзакцию и возвращает Transaction, контекст с транзакцией и ошибку.
+// Запросы внутри транзакции становятся дочерними span транзакции.
 func (s *Storage) BeginTx(ctx context.Context, opts *sql.TxOptions) (services.Transaction, context.Context, error) {
-	tx, err := s.db.BeginTxx(ctx, opts)
+	txCtx, span := tracer.Start(ctx, "Storage.Transaction", trace.WithSpanKind(trace.SpanKindClient))
+	tx, err := s.db.BeginTxx(txCtx, opts)
 	if err != nil {
+		span.RecordError(err)
+		span.End()
 		return nil, ctx, err
 	}
-	return tx, WithTx(ctx, tx), nil
+	return &tracedTx{Tx: tx, span: span}, WithTx(txCtx, tx), nil
 }
 
-func (s *Storage) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+func (s *Storage) GetUserByLogin(ctx context.Context, login string) (_ *models.User, err error) {
+	ctx, end := startQuery(ctx, "GetUserByLogin", "SELECT")
+	defer func() { end(err) }()
 	var user models.User
-	var err error
 	if tx, ok := GetTx(ctx); ok {
 		err = tx.GetContext(ctx, &user, "SELECT * FROM users WHERE login = $1", login)
 	} else {
@@ -76,15 +78,16 @@ func (s *Storage) GetUserByLogin(ctx context.Context, login string) (*models.Use
 	}
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("пользователь не найден")
+			return nil, services.ErrUserNotFound
 		}
 		return nil, err
 	}
 	return &user, nil
 }
-func (s *Storage) GetUserById(ctx context.Context, id int) (*models.User, error) {
+func (s *Storage) GetUserById(ctx context.Context, id int) (_ *models.User, err error) {
+	ctx, end := startQuery(ctx, "GetUserById", "SELECT")
+	defer func() { end(err) }()
 	var user models.User
-	var err error
 	if tx, ok := GetTx(ctx); ok {
 		err = tx.GetContext(ctx, &user, "SELECT * FROM users WHERE id = $1", id)
 	} else {
@@ -92,30 +95,18 @@ func (s *Storage) GetUserById(ctx context.Context, id int) (*models.User, error)
 	}
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("Пользователь не найден")
+			return nil, services.ErrUserNotFound
 		}
 		return nil, err
 	}
 	return &user, nil
 }
-func (s *Storage) GetAllUsers(ctx context.Context) ([]models.AllUser, error) {
-	var users []models.AllUser
-	var err error
-	if tx, ok := GetTx(ctx); ok {
-		err = tx.SelectContext(ctx, &users, "SELECT id, login, role FROM users ORDER BY login")
-	} else {
-		err = s.db.SelectContext(ctx, &users, "SELECT id, login, role FROM users ORDER BY login")
-	}
-	if err != nil {
-		return nil, err
-	}
-	return users, nil
-}
-func (s *Storage) CreateUser(ctx context.Context, user *models.User) error {
-	var err error
+func (s *Storage) CreateUser(ctx context.Context, user *models.User) (err error) {
+	ctx, end := startQuery(ctx, "CreateUser", "INSERT")
+	defer func() { end(err) }()
 	var rows *sqlx.Rows
-	query := `INSERT INTO users (login, password, role) 
-	          VALUES (:login, :password, :role) 
+	query := `INSERT INTO users (login, password, role, email, email_verified, must_change_password) 
+	          VALUES (:login, :password, :role, :email, :email_verified, :must_change_password) 
 	          RETURNING id`
 
 	if tx, ok := GetTx(ctx); ok {
@@ -124,10 +115,7 @@ func (s *Storage) CreateUser(ctx context.Context, user *models.User) error {
 		rows, err = s.db.NamedQueryContext(ctx, query, user)
 	}
 	if err != nil {
-		if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
-			return errors.New("пользователь уже существует")
-		}
-		return err
+		return mapUserError(ctx, err)
 	}
 	defer rows.Close()
 
@@ -139,17 +127,41 @@ func (s *Storage) CreateUser(ctx context.Context, user *models.User) error {
 	}
 	return nil
 }
-func (s *Storage) UpdateUser(ctx context.Context, user *models.User) error {
-	var err error
+func (s *Storage) UpdateUser(ctx context.Context, user *models.User) (err error) {
+	ctx, end := startQuery(ctx, "UpdateUser", "UPDATE")
+	defer func() { end(err) }()
 	var result sql.Result
 	query := `UPDATE users 
-              SET login = :login, password = :password, role = :role 
+              SET login = :login, password = :password, role = :role, email = :email,
+                  must_change_password = :must_change_password 
               WHERE id = :id`
 	if tx, ok := GetTx(ctx); ok {
 		result, err = tx.NamedExecContext(ctx, query, user)
 	} else {
 		result, err = s.db.NamedExecContext(ctx, query, user)
 	}
+	if err != nil {
+		return mapUserError(ctx, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return services.ErrUserNotFound
+	}
+
+	return nil
+}
+func (s *Storage) UpdatePassword(ctx context.Context, id int, hash string) (err error) {
+	ctx, end := startQuery(ctx, "UpdatePassword", "UPDATE")
+	defer func() { end(err) }()
+	var result sql.Result
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2", hash, id)
+	} else {
+		result, err = s.db.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2", hash, id)
+	}
 	if err != nil {
 		return err
 	}
@@ -158,13 +170,36 @@ func (s *Storage) UpdateUser(ctx context.Context, user *models.User) error {
 		return err
 	}
 	if rowsAffected == 0 {
-		return errors.New("ошибка обновления данных")
+		return services.ErrUserNotFound
 	}
+	return nil
+}
 
+// SetMustChangePassword устанавливает или снимает требование сменить пароль при входе.
+func (s *Storage) SetMustChangePassword(ctx context.Context, id int, value bool) (err error) {
+	ctx, end := startQuery(ctx, "SetMustChangePassword", "UPDATE")
+	defer func() { end(err) }()
+	var result sql.Result
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, "UPDATE users SET must_change_password = $1 WHERE id = $2", value, id)
+	} else {
+		result, err = s.db.ExecContext(ctx, "UPDATE users SET must_change_password = $1 WHERE id = $2", value, id)
+	}
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return services.ErrUserNotFound
+	}
 	return nil
 }
-func (s *Storage) DeleteUser(ctx context.Context, id int) error {
-	var err error
+func (s *Storage) DeleteUser(ctx context.Context, id int) (err error) {
+	ctx, end := startQuery(ctx, "DeleteUser", "DELETE")
+	defer func() { end(err) }()
 	var result sql.Result
 	if tx, ok := GetTx(ctx); ok {
 		result, err = tx.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
@@ -179,7 +214,29 @@ func (s *Storage) DeleteUser(ctx context.Context, id int) error {
 		return err
 	}
 	if rowsAffected == 0 {
-		return errors.New("пользователь не найден")
+		return services.ErrUserNotFound
+	}
+	return nil
+}
+
+// Ping проверяет соединение с базой данных.
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// CheckMigrationVersion проверяет, что схема базы на версии expected и последняя миграция применена полностью.
+func (s *Storage) CheckMigrationVersion(ctx context.Context, expected uint) error {
+	var version uint
+	var dirty bool
+	err := s.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("миграция %d применена не полностью", version)
+	}
+	if version != expected {
+		return fmt.Errorf("версия схемы %d, ожидается %d", version, expected)
 	}
 	return nil
 }
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"work/models"
+)
+
+// GetUserByEmail возвращает пользователя по адресу почты или nil, если такого нет.
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (_ *models.User, err error) {
+	ctx, end := startQuery(ctx, "GetUserByEmail", "SELECT")
+	defer func() { end(err) }()
+	var user models.User
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.GetContext(ctx, &user, "SELECT * FROM users WHERE email = $1", email)
+	} else {
+		err = s.db.GetContext(ctx, &user, "SELECT * FROM users WHERE email = $1", email)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *Storage) SetEmailVerified(ctx context.Context, userID int) (err error) {
+	ctx, end := startQuery(ctx, "SetEmailVerified", "UPDATE")
+	defer func() { end(err) }()
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, "UPDATE users SET email_verified = true WHERE id = $1", userID)
+	} else {
+		_, err = s.db.ExecContext(ctx, "UPDATE users SET email_verified = true WHERE id = $1", userID)
+	}
+	return err
+}
+
+func (s *Storage) CreateEmailVerificationToken(ctx context.Context, token *models.UserToken) (err error) {
+	ctx, end := startQuery(ctx, "CreateEmailVerificationToken", "INSERT")
+	defer func() { end(err) }()
+	return s.createUserToken(ctx, "email_verification_tokens", token)
+}
+
+func (s *Storage) GetEmailVerificationToken(ctx context.Context, hash string) (_ *models.UserToken, err error) {
+	ctx, end := startQuery(ctx, "GetEmailVerificationToken", "SELECT")
+	defer func() { end(err) }()
+	return s.getUserToken(ctx, "email_verification_tokens", hash)
+}
+
+func (s *Storage) MarkEmailVerificationTokenUsed(ctx context.Context, id int) (err error) {
+	ctx, end := startQuery(ctx, "MarkEmailVerificationTokenUsed", "UPDATE")
+	defer func() { end(err) }()
+	return s.markUserTokenUsed(ctx, "email_verification_tokens", id)
+}
+
+// createUserToken, getUserToken и markUserTokenUsed работают с таблицами одноразовых
+// токенов одинаковой структуры. table — всегда константа из кода, не ввод пользователя.
+func (s *Storage) createUserToken(ctx context.Context, table string, token *models.UserToken) error {
+	query := `INSERT INTO ` + table + ` (user_id, token_hash, expires_at)
+	          VALUES ($1, $2, $3)
+	          RETURNING id, created_at`
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.QueryRowxContext(ctx, query, token.UserID, token.TokenHash, token.ExpiresAt).
+			Scan(&token.ID, &token.CreatedAt)
+	} else {
+		err = s.db.QueryRowxContext(ctx, query, token.UserID, token.TokenHash, token.ExpiresAt).
+			Scan(&token.ID, &token.CreatedAt)
+	}
+	return err
+}
+
+// getUserToken возвращает токен по хэшу или nil; внутри транзакции строка блокируется.
+func (s *Storage) getUserToken(ctx context.Context, table, hash string) (*models.UserToken, error) {
+	var token models.UserToken
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.GetContext(ctx, &token, "SELECT * FROM "+table+" WHERE token_hash = $1 FOR UPDATE", hash)
+	} else {
+		err = s.db.GetContext(ctx, &token, "SELECT * FROM "+table+" WHERE token_hash = $1", hash)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *Storage) markUserTokenUsed(ctx context.Context, table string, id int) error {
+	var err error
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, "UPDATE "+table+" SET used_at = now() WHERE id = $1", id)
+	} else {
+		_, err = s.db.ExecContext(ctx, "UPDATE "+table+" SET used_at = now() WHERE id = $1", id)
+	}
+	return err
+}
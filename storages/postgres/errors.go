@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"work/services"
+
+	"github.com/lib/pq"
+)
+
+// Коды ошибок Postgres, которые переводятся в доменные ошибки.
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+	pqNotNullViolation    = "23502"
+	pqCheckViolation      = "23514"
+	pqStringTooLong       = "22001"
+	pqInvalidTextRepr     = "22P02"
+)
+
+// constraintErrors доменные ошибки для известных ограничений схемы.
+var constraintErrors = map[string]error{
+	"users_login_key":                  services.ErrUserExists,
+	"users_email_key":                  services.ErrUserExists,
+	"users_role_fkey":                  services.ErrUnknownRole,
+	"roles_pkey":                       services.ErrRoleExists,
+	"role_permissions_role_fkey":       services.ErrRoleNotFound,
+	"role_permissions_permission_fkey": services.ErrUnknownPermission,
+}
+
+// Ошибки для остальных нарушений: имя ограничения и текст Postgres описывают схему
+// и в ответ клиенту не попадают, только в лог.
+var (
+	errConflict         = services.NewError(services.ErrConflict, "conflict", "запись уже существует")
+	errInvalidReference = services.NewError(services.ErrValidation, "invalid_reference", "ссылка на несуществующую запись")
+	errInvalidValue     = services.NewError(services.ErrValidation, "invalid_value", "недопустимое значение")
+)
+
+// mapError переводит ошибку драйвера в доменную ошибку services по имени ограничения
+// или коду SQLSTATE. Остальные ошибки возвращаются без изменений.
+func mapError(ctx context.Context, err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	var mapped error
+	switch pqErr.Code {
+	case pqUniqueViolation:
+		mapped = errConflict
+	case pqForeignKeyViolation:
+		mapped = errInvalidReference
+	case pqNotNullViolation, pqCheckViolation, pqStringTooLong, pqInvalidTextRepr:
+		mapped = errInvalidValue
+	default:
+		return err
+	}
+	if known, ok := constraintErrors[pqErr.Constraint]; ok {
+		mapped = known
+	}
+	slog.InfoContext(ctx, "запрос нарушил ограничение базы данных",
+		"sqlstate", string(pqErr.Code), "constraint", pqErr.Constraint, "error", pqErr.Message)
+	return mapped
+}
+
+// mapUserError как mapError, но конфликт уникальности для users — это ErrUserExists.
+func mapUserError(ctx context.Context, err error) error {
+	err = mapError(ctx, err)
+	if errors.Is(err, services.ErrConflict) {
+		return services.ErrUserExists
+	}
+	return err
+}
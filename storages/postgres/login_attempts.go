@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"work/models"
+)
+
+// GetLoginAttempt возвращает счетчик неудачных попыток или nil, если их не было.
+func (s *Storage) GetLoginAttempt(ctx context.Context, scope, key string) (_ *models.LoginAttempt, err error) {
+	ctx, end := startQuery(ctx, "GetLoginAttempt", "SELECT")
+	defer func() { end(err) }()
+	var attempt models.LoginAttempt
+	query := "SELECT * FROM login_attempts WHERE scope = $1 AND key = $2"
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.GetContext(ctx, &attempt, query, scope, key)
+	} else {
+		err = s.db.GetContext(ctx, &attempt, query, scope, key)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// RecordLoginFailure атомарно увеличивает счетчик и возвращает новое значение.
+// Если последняя ошибка была раньше window, счет начинается заново.
+func (s *Storage) RecordLoginFailure(ctx context.Context, scope, key string, window time.Duration) (_ int, err error) {
+	ctx, end := startQuery(ctx, "RecordLoginFailure", "INSERT")
+	defer func() { end(err) }()
+	query := `INSERT INTO login_attempts (scope, key, failures, last_failure_at)
+	          VALUES ($1, $2, 1, now())
+	          ON CONFLICT (scope, key) DO UPDATE
+	          SET failures = CASE
+	                  WHEN login_attempts.last_failure_at < now() - make_interval(secs => $3) THEN 1
+	                  ELSE login_attempts.failures + 1
+	              END,
+	              last_failure_at = now()
+	          RETURNING failures`
+	var failures int
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.GetContext(ctx, &failures, query, scope, key, window.Seconds())
+	} else {
+		err = s.db.GetContext(ctx, &failures, query, scope, key, window.Seconds())
+	}
+	return failures, err
+}
+
+func (s *Storage) SetLoginLockedUntil(ctx context.Context, scope, key string, until time.Time) (err error) {
+	ctx, end := startQuery(ctx, "SetLoginLockedUntil", "UPDATE")
+	defer func() { end(err) }()
+	query := "UPDATE login_attempts SET locked_until = $3 WHERE scope = $1 AND key = $2"
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, scope, key, until)
+	} else {
+		_, err = s.db.ExecContext(ctx, query, scope, key, until)
+	}
+	return err
+}
+
+func (s *Storage) ResetLoginAttempts(ctx context.Context, scope, key string) (err error) {
+	ctx, end := startQuery(ctx, "ResetLoginAttempts", "DELETE")
+	defer func() { end(err) }()
+	query := "DELETE FROM login_attempts WHERE scope = $1 AND key = $2"
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, scope, key)
+	} else {
+		_, err = s.db.ExecContext(ctx, query, scope, key)
+	}
+	return err
+}
+
+// DeleteStaleLoginAttempts удаляет счетчики, у которых истекли и окно подсчета
+// (последняя ошибка раньше olderThan), и блокировка. Возвращает число удаленных записей.
+func (s *Storage) DeleteStaleLoginAttempts(ctx context.Context, olderThan time.Duration) (_ int64, err error) {
+	ctx, end := startQuery(ctx, "DeleteStaleLoginAttempts", "DELETE")
+	defer func() { end(err) }()
+	query := `DELETE FROM login_attempts
+	          WHERE last_failure_at < now() - make_interval(secs => $1)
+	            AND (locked_until IS NULL OR locked_until < now())`
+	var res sql.Result
+	if tx, ok := GetTx(ctx); ok {
+		res, err = tx.ExecContext(ctx, query, olderThan.Seconds())
+	} else {
+		res, err = s.db.ExecContext(ctx, query, olderThan.Seconds())
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
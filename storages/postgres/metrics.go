@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// queryDuration время выполнения методов Storage; метка — имя метода.
+var queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "db_query_duration_seconds",
+	Help:    "Время выполнения запросов к базе по методам Storage.",
+	Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"method"})
+
+// RegisterMetrics регистрирует время запросов и статистику пула соединений sqlx.
+func (s *Storage) RegisterMetrics(reg prometheus.Registerer) error {
+	if err := reg.Register(queryDuration); err != nil {
+		return err
+	}
+	return reg.Register(collectors.NewDBStatsCollector(s.db.DB, "workspace"))
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// SetMFASecret сохраняет секрет TOTP (nil — удалить) и признак включения второго фактора.
+func (s *Storage) SetMFASecret(ctx context.Context, userID int, secret *string, enabled bool) (err error) {
+	ctx, end := startQuery(ctx, "SetMFASecret", "UPDATE")
+	defer func() { end(err) }()
+	query := "UPDATE users SET mfa_secret = $1, mfa_enabled = $2, mfa_last_step = NULL WHERE id = $3"
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, secret, enabled, userID)
+	} else {
+		_, err = s.db.ExecContext(ctx, query, secret, enabled, userID)
+	}
+	return err
+}
+
+// AdvanceMFAStep запоминает шаг использованного кода TOTP.
+// Возвращает false, если код этого или более позднего шага уже применялся.
+func (s *Storage) AdvanceMFAStep(ctx context.Context, userID int, step int64) (_ bool, err error) {
+	ctx, end := startQuery(ctx, "AdvanceMFAStep", "UPDATE")
+	defer func() { end(err) }()
+	var result sql.Result
+	query := `UPDATE users SET mfa_last_step = $1
+	          WHERE id = $2 AND (mfa_last_step IS NULL OR mfa_last_step < $1)`
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, step, userID)
+	} else {
+		result, err = s.db.ExecContext(ctx, query, step, userID)
+	}
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes удаляет старые коды восстановления и сохраняет хэши новых.
+func (s *Storage) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) (err error) {
+	ctx, end := startQuery(ctx, "ReplaceRecoveryCodes", "INSERT")
+	defer func() { end(err) }()
+	tx, ok := GetTx(ctx)
+	if !ok {
+		return errors.New("замена кодов восстановления должна выполняться в транзакции")
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		_, err := tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode гасит код восстановления. Возвращает false, если код неверный или уже использован.
+func (s *Storage) UseRecoveryCode(ctx context.Context, userID int, hash string) (_ bool, err error) {
+	ctx, end := startQuery(ctx, "UseRecoveryCode", "UPDATE")
+	defer func() { end(err) }()
+	var result sql.Result
+	query := `UPDATE recovery_codes SET used_at = now()
+	          WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, query, userID, hash)
+	} else {
+		result, err = s.db.ExecContext(ctx, query, userID, hash)
+	}
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
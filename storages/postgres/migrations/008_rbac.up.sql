@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+    );
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(100) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+    );
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+    );
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:create', 'Создание пользователей'),
+    ('users:update', 'Изменение пользователей'),
+    ('users:delete', 'Удаление пользователей'),
+    ('users:sessions', 'Завершение сессий пользователей'),
+    ('users:unlock', 'Снятие блокировки входа'),
+    ('roles:read', 'Просмотр ролей и прав'),
+    ('roles:manage', 'Создание, изменение и удаление ролей')
+    ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Администратор'),
+    ('user', 'Пользователь')
+    ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions
+    ON CONFLICT DO NOTHING;
+
+-- роли, уже назначенные пользователям (в том числе с опечатками), переносим как есть,
+-- чтобы можно было добавить внешний ключ; права им администратор выдаст отдельно
+INSERT INTO roles (name)
+SELECT DISTINCT role FROM users
+    ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE users
+    ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
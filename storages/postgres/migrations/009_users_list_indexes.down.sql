@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_role_id_idx;
+DROP INDEX IF EXISTS users_role_login_idx;
+DROP INDEX IF EXISTS users_login_pattern_idx;
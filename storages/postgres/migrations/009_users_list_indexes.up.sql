@@ -0,0 +1,5 @@
+-- поиск по началу логина (LIKE 'abc%') и сортировка по логину
+CREATE INDEX IF NOT EXISTS users_login_pattern_idx ON users (login varchar_pattern_ops, id);
+-- фильтр и сортировка по роли
+CREATE INDEX IF NOT EXISTS users_role_login_idx ON users (role, login, id);
+CREATE INDEX IF NOT EXISTS users_role_id_idx ON users (role, id);
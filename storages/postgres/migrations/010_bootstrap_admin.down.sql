@@ -0,0 +1,2 @@
+-- удаленная учетная запись admin/admin не восстанавливается
+ALTER TABLE users DROP COLUMN IF EXISTS must_change_password;
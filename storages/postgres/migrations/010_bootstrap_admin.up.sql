@@ -0,0 +1,12 @@
+-- пароль, выданный при первоначальной настройке, нужно сменить при первом входе
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT false;
+
+-- учетную запись admin/admin, которую создает первая миграция, удаляем, если пароль
+-- не меняли и по нему ни разу не входили (при входе хэш SHA-256 заменяется на argon2id).
+-- Примененные миграции не меняются, поэтому удаление здесь, а не в 001.
+-- Если по нему уже входили, хэш в SQL не проверить: такую учетную запись находит
+-- проверка при запуске сервера (DefaultCredentials), и сервер с ней не запускается.
+-- Первого администратора создает сервер при запуске из bootstrap.admin_*
+DELETE FROM users
+WHERE login = 'admin'
+  AND password = '8c6976e5b5410415bde908bd4dee15dfb167a9c873fc4bb8a81f6f2ab448a918';
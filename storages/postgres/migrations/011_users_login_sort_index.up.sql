@@ -0,0 +1,3 @@
+-- varchar_pattern_ops из 009 обслуживает только LIKE 'abc%'; сортировка и keyset-условие
+-- (login, id) > (...) в правилах сравнения базы требуют обычного индекса
+CREATE INDEX IF NOT EXISTS users_login_id_idx ON users (login, id);
@@ -0,0 +1,8 @@
+-- чтение пользователей через gRPC (GetUser, ListUsers) требует отдельного права
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'Просмотр пользователей')
+    ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'users:read')
+    ON CONFLICT DO NOTHING;
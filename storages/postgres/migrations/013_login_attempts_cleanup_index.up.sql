@@ -0,0 +1,2 @@
+-- периодическая очистка удаляет счетчики по времени последней ошибки
+CREATE INDEX IF NOT EXISTS login_attempts_last_failure_at_idx ON login_attempts (last_failure_at);
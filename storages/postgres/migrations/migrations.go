@@ -0,0 +1,9 @@
+// Package migrations SQL-миграции схемы базы, встроенные в бинарники server и workctl.
+package migrations
+
+import "embed"
+
+// FS файлы NNN_name.up.sql и NNN_name.down.sql в корне.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -1,9 +1,9 @@
 package postgres
 
 import (
-	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -16,9 +16,9 @@ type Migrator struct {
 	srcDriver source.Driver // Драйвер источника миграций.
 }
 
-// MustGetNewMigrator создает новый экземпляр Migrator с встроенными SQL-файлами миграций.
-// В случае ошибки вызывает panic.
-func MustGetNewMigrator(sqlFiles embed.FS, dirName string) *Migrator {
+// MustGetNewMigrator создает новый экземпляр Migrator с встроенными SQL-файлами миграций
+// (обычно migrations.FS и каталог "."). В случае ошибки вызывает panic.
+func MustGetNewMigrator(sqlFiles fs.FS, dirName string) *Migrator {
 	// Создаем новый драйвер источника миграций с встроенными SQL-файлами.
 	d, err := iofs.New(sqlFiles, dirName)
 	if err != nil {
@@ -29,29 +29,31 @@ func MustGetNewMigrator(sqlFiles embed.FS, dirName string) *Migrator {
 	}
 }
 
-// ApplyMigrations применяет миграции к базе данных.
-func (m *Migrator) ApplyMigrations(storage *Storage) error {
-	db := storage.db.DB //для реализации с sqlx.db, разварачивает в sql.db
-	// Создаем экземпляр драйвера базы данных для PostgreSQL.
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+// LatestVersion версия последней встроенной миграции; с ней сверяется схема базы в /readyz.
+func (m *Migrator) LatestVersion() (uint, error) {
+	version, err := m.srcDriver.First()
 	if err != nil {
-		return fmt.Errorf("unable to create db instance: %v", err)
+		return 0, err
+	}
+	for {
+		next, err := m.srcDriver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
 	}
+}
 
-	// Создаем новый экземпляр мигратора с использованием драйвера источника и драйвера базы данных PostgreSQL.
-	migrator, err := migrate.NewWithInstance("migration_embeded_sql_files", m.srcDriver, "psql_db", driver)
+// ApplyMigrations применяет миграции к базе данных.
+func (m *Migrator) ApplyMigrations(storage *Storage) error {
+	migrator, err := m.newMigrate(storage)
 	if err != nil {
-		return fmt.Errorf("unable to create migration: %v", err)
+		return err
 	}
 
-	// Закрываем мигратор в конце работы функции.
-	// defer func() {
-
-	// 	if migrator != nil || errors.Is(err, migrate.ErrNoChange) {
-	// 		migrator.Close()
-	// 	}
-	// }()
-
 	// Применяем миграции.
 	if err = migrator.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		return fmt.Errorf("unable to apply migrations %v", err)
@@ -59,3 +61,68 @@ func (m *Migrator) ApplyMigrations(storage *Storage) error {
 
 	return nil
 }
+
+// Down откатывает steps последних миграций.
+func (m *Migrator) Down(storage *Storage, steps int) error {
+	migrator, err := m.newMigrate(storage)
+	if err != nil {
+		return err
+	}
+	if err = migrator.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("unable to roll back migrations: %v", err)
+	}
+	return nil
+}
+
+// Goto переводит схему на версию version вверх или вниз.
+func (m *Migrator) Goto(storage *Storage, version uint) error {
+	migrator, err := m.newMigrate(storage)
+	if err != nil {
+		return err
+	}
+	if err = migrator.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("unable to migrate to version %d: %v", version, err)
+	}
+	return nil
+}
+
+// Force записывает версию схемы без выполнения миграций и снимает признак dirty.
+// Нужен после ручного исправления базы, когда миграция упала на середине.
+func (m *Migrator) Force(storage *Storage, version int) error {
+	migrator, err := m.newMigrate(storage)
+	if err != nil {
+		return err
+	}
+	return migrator.Force(version)
+}
+
+// Version текущая версия схемы; ok = false, если миграции еще не применялись.
+func (m *Migrator) Version(storage *Storage) (version uint, dirty, ok bool, err error) {
+	migrator, err := m.newMigrate(storage)
+	if err != nil {
+		return 0, false, false, err
+	}
+	version, dirty, err = migrator.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, false, nil
+	}
+	return version, dirty, err == nil, err
+}
+
+// newMigrate мигратор поверх соединения storage. Не закрывается:
+// Close драйвера postgres закрыл бы и общее соединение с базой.
+func (m *Migrator) newMigrate(storage *Storage) (*migrate.Migrate, error) {
+	db := storage.db.DB //для реализации с sqlx.db, разварачивает в sql.db
+	// Создаем экземпляр драйвера базы данных для PostgreSQL.
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create db instance: %v", err)
+	}
+
+	// Создаем новый экземпляр мигратора с использованием драйвера источника и драйвера базы данных PostgreSQL.
+	migrator, err := migrate.NewWithInstance("migration_embeded_sql_files", m.srcDriver, "psql_db", driver)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create migration: %v", err)
+	}
+	return migrator, nil
+}
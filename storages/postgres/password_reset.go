@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"context"
+	"work/models"
+)
+
+func (s *Storage) CreatePasswordResetToken(ctx context.Context, token *models.UserToken) (err error) {
+	ctx, end := startQuery(ctx, "CreatePasswordResetToken", "INSERT")
+	defer func() { end(err) }()
+	return s.createUserToken(ctx, "password_reset_tokens", token)
+}
+
+func (s *Storage) GetPasswordResetToken(ctx context.Context, hash string) (_ *models.UserToken, err error) {
+	ctx, end := startQuery(ctx, "GetPasswordResetToken", "SELECT")
+	defer func() { end(err) }()
+	return s.getUserToken(ctx, "password_reset_tokens", hash)
+}
+
+// InvalidatePasswordResetTokens гасит все неиспользованные токены сброса пароля пользователя.
+func (s *Storage) InvalidatePasswordResetTokens(ctx context.Context, userID int) (err error) {
+	ctx, end := startQuery(ctx, "InvalidatePasswordResetTokens", "UPDATE")
+	defer func() { end(err) }()
+	query := "UPDATE password_reset_tokens SET used_at = now() WHERE user_id = $1 AND used_at IS NULL"
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, userID)
+	} else {
+		_, err = s.db.ExecContext(ctx, query, userID)
+	}
+	return err
+}
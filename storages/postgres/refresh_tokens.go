@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"work/models"
+)
+
+func (s *Storage) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (err error) {
+	ctx, end := startQuery(ctx, "CreateRefreshToken", "INSERT")
+	defer func() { end(err) }()
+	query := `INSERT INTO refresh_tokens (user_id, family_id, token_hash, mfa, expires_at)
+	          VALUES ($1, $2, $3, $4, $5)
+	          RETURNING id, created_at`
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.QueryRowxContext(ctx, query, token.UserID, token.FamilyID, token.TokenHash, token.MFA, token.ExpiresAt).
+			Scan(&token.ID, &token.CreatedAt)
+	} else {
+		err = s.db.QueryRowxContext(ctx, query, token.UserID, token.FamilyID, token.TokenHash, token.MFA, token.ExpiresAt).
+			Scan(&token.ID, &token.CreatedAt)
+	}
+	return err
+}
+
+// GetRefreshTokenByHash возвращает токен по хэшу или nil, если такого нет.
+// Внутри транзакции строка блокируется до ее завершения.
+func (s *Storage) GetRefreshTokenByHash(ctx context.Context, hash string) (_ *models.RefreshToken, err error) {
+	ctx, end := startQuery(ctx, "GetRefreshTokenByHash", "SELECT")
+	defer func() { end(err) }()
+	var token models.RefreshToken
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.GetContext(ctx, &token, "SELECT * FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE", hash)
+	} else {
+		err = s.db.GetContext(ctx, &token, "SELECT * FROM refresh_tokens WHERE token_hash = $1", hash)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *Storage) MarkRefreshTokenUsed(ctx context.Context, id int) (err error) {
+	ctx, end := startQuery(ctx, "MarkRefreshTokenUsed", "UPDATE")
+	defer func() { end(err) }()
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = now() WHERE id = $1", id)
+	} else {
+		_, err = s.db.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = now() WHERE id = $1", id)
+	}
+	return err
+}
+
+func (s *Storage) RevokeRefreshTokenFamily(ctx context.Context, familyID string) (err error) {
+	ctx, end := startQuery(ctx, "RevokeRefreshTokenFamily", "UPDATE")
+	defer func() { end(err) }()
+	query := "UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL"
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, familyID)
+	} else {
+		_, err = s.db.ExecContext(ctx, query, familyID)
+	}
+	return err
+}
+
+func (s *Storage) RevokeUserRefreshTokens(ctx context.Context, userID int) (err error) {
+	ctx, end := startQuery(ctx, "RevokeUserRefreshTokens", "UPDATE")
+	defer func() { end(err) }()
+	query := "UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL"
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, userID)
+	} else {
+		_, err = s.db.ExecContext(ctx, query, userID)
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// RevokeToken сохраняет jti отозванного токена и заодно чистит записи с истекшим сроком.
+func (s *Storage) RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) (err error) {
+	ctx, end := startQuery(ctx, "RevokeToken", "INSERT")
+	defer func() { end(err) }()
+	insert := `INSERT INTO revoked_tokens (jti, user_id, expires_at)
+	           VALUES ($1, $2, $3)
+	           ON CONFLICT (jti) DO NOTHING`
+	purge := "DELETE FROM revoked_tokens WHERE expires_at < now()"
+	if tx, ok := GetTx(ctx); ok {
+		if _, err = tx.ExecContext(ctx, insert, jti, userID, expiresAt); err == nil {
+			_, err = tx.ExecContext(ctx, purge)
+		}
+	} else {
+		if _, err = s.db.ExecContext(ctx, insert, jti, userID, expiresAt); err == nil {
+			_, err = s.db.ExecContext(ctx, purge)
+		}
+	}
+	return err
+}
+
+// DeleteExpiredRevokedTokens удаляет записи об отозванных токенах, срок которых истек:
+// такие токены и так не проходят проверку. Использует индекс по expires_at.
+func (s *Storage) DeleteExpiredRevokedTokens(ctx context.Context) (_ int64, err error) {
+	ctx, end := startQuery(ctx, "DeleteExpiredRevokedTokens", "DELETE")
+	defer func() { end(err) }()
+	query := "DELETE FROM revoked_tokens WHERE expires_at < now()"
+	var res sql.Result
+	if tx, ok := GetTx(ctx); ok {
+		res, err = tx.ExecContext(ctx, query)
+	} else {
+		res, err = s.db.ExecContext(ctx, query)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Storage) IsTokenRevoked(ctx context.Context, jti string) (_ bool, err error) {
+	ctx, end := startQuery(ctx, "IsTokenRevoked", "SELECT")
+	defer func() { end(err) }()
+	var revoked bool
+	query := "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)"
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.GetContext(ctx, &revoked, query, jti)
+	} else {
+		err = s.db.GetContext(ctx, &revoked, query, jti)
+	}
+	return revoked, err
+}
+
+// GetTokensValidAfter возвращает границу валидности токенов пользователя или nil, если пользователя нет.
+func (s *Storage) GetTokensValidAfter(ctx context.Context, userID int) (_ *time.Time, err error) {
+	ctx, end := startQuery(ctx, "GetTokensValidAfter", "SELECT")
+	defer func() { end(err) }()
+	var validAfter time.Time
+	query := "SELECT tokens_valid_after FROM users WHERE id = $1"
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.GetContext(ctx, &validAfter, query, userID)
+	} else {
+		err = s.db.GetContext(ctx, &validAfter, query, userID)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &validAfter, nil
+}
+
+func (s *Storage) SetTokensValidAfter(ctx context.Context, userID int, t time.Time) (err error) {
+	ctx, end := startQuery(ctx, "SetTokensValidAfter", "UPDATE")
+	defer func() { end(err) }()
+	query := "UPDATE users SET tokens_valid_after = $1 WHERE id = $2"
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, t, userID)
+	} else {
+		_, err = s.db.ExecContext(ctx, query, t, userID)
+	}
+	return err
+}
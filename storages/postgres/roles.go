@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"work/models"
+	"work/services"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// roleRow строка роли с правами, собранными в массив.
+type roleRow struct {
+	Name        string         `db:"name"`
+	Description string         `db:"description"`
+	Permissions pq.StringArray `db:"permissions"`
+}
+
+func (r roleRow) toModel() models.Role {
+	perms := []string(r.Permissions)
+	if perms == nil {
+		perms = []string{}
+	}
+	return models.Role{Name: r.Name, Description: r.Description, Permissions: perms}
+}
+
+const selectRoles = `SELECT r.name, r.description,
+	       COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}') AS permissions
+	FROM roles r
+	LEFT JOIN role_permissions rp ON rp.role = r.name`
+
+func (s *Storage) ListRoles(ctx context.Context) (_ []models.Role, err error) {
+	ctx, end := startQuery(ctx, "ListRoles", "SELECT")
+	defer func() { end(err) }()
+	var rows []roleRow
+	query := selectRoles + " GROUP BY r.name ORDER BY r.name"
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.SelectContext(ctx, &rows, query)
+	} else {
+		err = s.db.SelectContext(ctx, &rows, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]models.Role, 0, len(rows))
+	for _, r := range rows {
+		roles = append(roles, r.toModel())
+	}
+	return roles, nil
+}
+
+// GetRole возвращает роль или nil, если ее нет.
+func (s *Storage) GetRole(ctx context.Context, name string) (_ *models.Role, err error) {
+	ctx, end := startQuery(ctx, "GetRole", "SELECT")
+	defer func() { end(err) }()
+	var row roleRow
+	query := selectRoles + " WHERE r.name = $1 GROUP BY r.name"
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.GetContext(ctx, &row, query, name)
+	} else {
+		err = s.db.GetContext(ctx, &row, query, name)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	role := row.toModel()
+	return &role, nil
+}
+
+// CreateRole создает роль с правами. Вызывается внутри транзакции.
+func (s *Storage) CreateRole(ctx context.Context, role *models.Role) (err error) {
+	ctx, end := startQuery(ctx, "CreateRole", "INSERT")
+	defer func() { end(err) }()
+	tx, ok := GetTx(ctx)
+	if !ok {
+		return errors.New("создание роли должно выполняться в транзакции")
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO roles (name, description) VALUES ($1, $2)", role.Name, role.Description)
+	if err != nil {
+		return mapError(ctx, err)
+	}
+	return setRolePermissions(ctx, tx, role.Name, role.Permissions)
+}
+
+// UpdateRole меняет описание и полностью заменяет права роли. Вызывается внутри транзакции.
+func (s *Storage) UpdateRole(ctx context.Context, role *models.Role) (err error) {
+	ctx, end := startQuery(ctx, "UpdateRole", "UPDATE")
+	defer func() { end(err) }()
+	tx, ok := GetTx(ctx)
+	if !ok {
+		return errors.New("изменение роли должно выполняться в транзакции")
+	}
+	result, err := tx.ExecContext(ctx, "UPDATE roles SET description = $1 WHERE name = $2", role.Description, role.Name)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return services.ErrRoleNotFound
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role = $1", role.Name); err != nil {
+		return err
+	}
+	return setRolePermissions(ctx, tx, role.Name, role.Permissions)
+}
+
+func setRolePermissions(ctx context.Context, tx *sqlx.Tx, role string, permissions []string) error {
+	for _, perm := range permissions {
+		_, err := tx.ExecContext(ctx, "INSERT INTO role_permissions (role, permission) VALUES ($1, $2)", role, perm)
+		if err != nil {
+			return mapError(ctx, err)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) DeleteRole(ctx context.Context, name string) (err error) {
+	ctx, end := startQuery(ctx, "DeleteRole", "DELETE")
+	defer func() { end(err) }()
+	var result sql.Result
+	if tx, ok := GetTx(ctx); ok {
+		result, err = tx.ExecContext(ctx, "DELETE FROM roles WHERE name = $1", name)
+	} else {
+		result, err = s.db.ExecContext(ctx, "DELETE FROM roles WHERE name = $1", name)
+	}
+	if err != nil {
+		return mapError(ctx, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return services.ErrRoleNotFound
+	}
+	return nil
+}
+
+func (s *Storage) ListPermissions(ctx context.Context) (_ []models.Permission, err error) {
+	ctx, end := startQuery(ctx, "ListPermissions", "SELECT")
+	defer func() { end(err) }()
+	var perms []models.Permission
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.SelectContext(ctx, &perms, "SELECT name, description FROM permissions ORDER BY name")
+	} else {
+		err = s.db.SelectContext(ctx, &perms, "SELECT name, description FROM permissions ORDER BY name")
+	}
+	return perms, err
+}
+
+// CountUsersWithRole нужен, чтобы не удалять назначенную пользователям роль.
+func (s *Storage) CountUsersWithRole(ctx context.Context, role string) (_ int, err error) {
+	ctx, end := startQuery(ctx, "CountUsersWithRole", "SELECT")
+	defer func() { end(err) }()
+	var count int
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.GetContext(ctx, &count, "SELECT count(*) FROM users WHERE role = $1", role)
+	} else {
+		err = s.db.GetContext(ctx, &count, "SELECT count(*) FROM users WHERE role = $1", role)
+	}
+	return count, err
+}
+
+// RevokeRoleTokens отзывает токены всех пользователей роли: права в них устарели.
+func (s *Storage) RevokeRoleTokens(ctx context.Context, role string, t time.Time) (err error) {
+	ctx, end := startQuery(ctx, "RevokeRoleTokens", "UPDATE")
+	defer func() { end(err) }()
+	query := "UPDATE users SET tokens_valid_after = $1 WHERE role = $2"
+	if tx, ok := GetTx(ctx); ok {
+		_, err = tx.ExecContext(ctx, query, t, role)
+	} else {
+		_, err = s.db.ExecContext(ctx, query, t, role)
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("work/storages/postgres")
+
+// startQuery открывает span запроса и учитывает его время в db_query_duration_seconds.
+// В span попадает только тип оператора (SELECT, INSERT...), параметры запроса — нет.
+// end получает ошибку, которую возвращает метод хранилища: она отмечается в span.
+//
+//	func (s *Storage) GetUserById(ctx context.Context, id int) (_ *models.User, err error) {
+//		ctx, end := startQuery(ctx, "GetUserById", "SELECT")
+//		defer func() { end(err) }()
+func startQuery(ctx context.Context, method, operation string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "Storage."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", operation),
+		))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		queryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// tracedTx транзакция, span которой закрывается при Commit или Rollback.
+type tracedTx struct {
+	*sqlx.Tx
+	span trace.Span
+}
+
+func (t *tracedTx) Commit() error {
+	err := t.Tx.Commit()
+	if err != nil {
+		t.span.RecordError(err)
+		t.span.SetStatus(codes.Error, "commit failed")
+	}
+	t.span.SetAttributes(attribute.Bool("db.transaction.committed", err == nil))
+	t.span.End()
+	return err
+}
+
+func (t *tracedTx) Rollback() error {
+	err := t.Tx.Rollback()
+	// Rollback после Commit (defer tx.Rollback()) ничего не делает, span уже закрыт
+	if errors.Is(err, sql.ErrTxDone) {
+		return err
+	}
+	t.span.SetAttributes(attribute.Bool("db.transaction.committed", false))
+	t.span.End()
+	return err
+}
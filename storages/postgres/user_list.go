@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"work/models"
+)
+
+// sortColumns допустимые поля сортировки; имя колонки в запрос подставляется только отсюда.
+var sortColumns = map[string]string{
+	"login": "login",
+	"id":    "id",
+	"role":  "role",
+}
+
+// userListWhere строит условия фильтра; cursor = true добавляет условие keyset-пагинации.
+func userListWhere(f models.UserListFilter, cursor bool) (string, []any, error) {
+	var conds []string
+	var args []any
+	if f.Role != "" {
+		args = append(args, f.Role)
+		conds = append(conds, fmt.Sprintf("role = $%d", len(args)))
+	}
+	if f.LoginPrefix != "" {
+		args = append(args, escapeLike(f.LoginPrefix)+"%")
+		conds = append(conds, fmt.Sprintf("login LIKE $%d", len(args)))
+	}
+	if cursor && f.HasAfter {
+		op := ">"
+		if f.Desc {
+			op = "<"
+		}
+		if f.SortField == "id" {
+			args = append(args, f.AfterID)
+			conds = append(conds, fmt.Sprintf("id %s $%d", op, len(args)))
+		} else {
+			col, ok := sortColumns[f.SortField]
+			if !ok {
+				return "", nil, fmt.Errorf("недопустимое поле сортировки: %s", f.SortField)
+			}
+			args = append(args, f.AfterValue, f.AfterID)
+			conds = append(conds, fmt.Sprintf("(%s, id) %s ($%d, $%d)", col, op, len(args)-1, len(args)))
+		}
+	}
+	if len(conds) == 0 {
+		return "", args, nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы префикс искался буквально.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ListUsers возвращает страницу пользователей с фильтрами и keyset-пагинацией.
+func (s *Storage) ListUsers(ctx context.Context, f models.UserListFilter) (_ []models.AllUser, err error) {
+	ctx, end := startQuery(ctx, "ListUsers", "SELECT")
+	defer func() { end(err) }()
+	col, ok := sortColumns[f.SortField]
+	if !ok {
+		return nil, fmt.Errorf("недопустимое поле сортировки: %s", f.SortField)
+	}
+	where, args, err := userListWhere(f, true)
+	if err != nil {
+		return nil, err
+	}
+	dir := "ASC"
+	if f.Desc {
+		dir = "DESC"
+	}
+	order := fmt.Sprintf("%s %s, id %s", col, dir, dir)
+	if col == "id" {
+		order = "id " + dir
+	}
+	args = append(args, f.Limit)
+	query := fmt.Sprintf("SELECT id, login, role FROM users%s ORDER BY %s LIMIT $%d", where, order, len(args))
+
+	users := []models.AllUser{}
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.SelectContext(ctx, &users, query, args...)
+	} else {
+		err = s.db.SelectContext(ctx, &users, query, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CountUsers считает пользователей, подходящих под фильтр (без учета курсора).
+func (s *Storage) CountUsers(ctx context.Context, f models.UserListFilter) (_ int, err error) {
+	ctx, end := startQuery(ctx, "CountUsers", "SELECT")
+	defer func() { end(err) }()
+	where, args, err := userListWhere(f, false)
+	if err != nil {
+		return 0, err
+	}
+	var total int
+	query := "SELECT count(*) FROM users" + where
+	if tx, ok := GetTx(ctx); ok {
+		err = tx.GetContext(ctx, &total, query, args...)
+	} else {
+		err = s.db.GetContext(ctx, &total, query, args...)
+	}
+	return total, err
+}
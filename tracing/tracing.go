@@ -0,0 +1,107 @@
+// Package tracing настройка OpenTelemetry: провайдер трассировки, экспортер и
+// распространение контекста W3C traceparent.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ServiceName имя сервиса в трассах, если не задан OTEL_SERVICE_NAME.
+const ServiceName = "users-api"
+
+// Config выбор экспортера трасс.
+type Config struct {
+	// Exporter: "" или none — трассы не пишутся, stderr, file (File) или otlp.
+	// stdout занят JSON-логами, поэтому span туда не пишутся: прежнее имя stdout
+	// означает stderr. Адрес коллектора OTLP берется из переменных OTEL_EXPORTER_OTLP_*.
+	Exporter string
+	// File файл для экспортера file: по одному JSON-объекту span на строку.
+	File string
+	// SampleRatio доля записываемых трасс, от 0 (ни одной) до 1 (все).
+	SampleRatio float64
+}
+
+// Setup устанавливает глобальный провайдер трассировки и распространитель контекста.
+// Возвращает функцию, которая отправляет накопленные span и останавливает провайдер.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closer, err := newExporter(ctx, cfg)
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", ServiceName)),
+		resource.WithFromEnv(), // OTEL_SERVICE_NAME и OTEL_RESOURCE_ATTRIBUTES имеют приоритет
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var sampler sdktrace.Sampler
+	switch {
+	case cfg.SampleRatio >= 1:
+		sampler = sdktrace.AlwaysSample()
+	case cfg.SampleRatio <= 0:
+		sampler = sdktrace.NeverSample()
+	default:
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch cfg.Exporter {
+	case "", "none":
+		return nil, nil, nil
+	case "stderr", "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+		return exp, nil, err
+	case "file":
+		if cfg.File == "" {
+			return nil, nil, errors.New("для экспортера file нужно указать файл")
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exp, f, nil
+	case "otlp":
+		exp, err := otlptracegrpc.New(ctx)
+		return exp, nil, err
+	}
+	return nil, nil, fmt.Errorf("неизвестный экспортер трасс %q", cfg.Exporter)
+}